@@ -0,0 +1,75 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const maxBitcrusherBits = 16
+
+// Bitcrusher holds the settings of a bit depth and sample rate reducer
+type Bitcrusher struct {
+	Bits       int // bit depth [1..16], 0 = no bit depth reduction
+	Downsample int // hold each sample for this many samples, 0 or 1 = no rate reduction
+}
+
+// Active reports whether the bitcrusher alters the signal
+func (b Bitcrusher) Active() bool {
+	return (b.Bits > 0 && b.Bits < maxBitcrusherBits) || b.Downsample > 1
+}
+
+// bitcrusherEffect implements beep.Streamer for bit depth and sample rate reduction
+type bitcrusherEffect struct {
+	Streamer   beep.Streamer
+	bitcrusher Bitcrusher
+	held       [2]float64
+	holdCount  int
+}
+
+func newBitcrusherEffect(streamer beep.Streamer, bitcrusher Bitcrusher) *bitcrusherEffect {
+	return &bitcrusherEffect{
+		Streamer:   streamer,
+		bitcrusher: bitcrusher,
+	}
+}
+
+// Stream fills the samples buffer with the crushed signal
+func (b *bitcrusherEffect) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = b.Streamer.Stream(samples)
+
+	if !b.bitcrusher.Active() {
+		return n, ok
+	}
+
+	downsample := max(b.bitcrusher.Downsample, 1)
+
+	// Quantization step for a signal in the range [-1..1], rounded to the 2^Bits levels of a
+	// signed integer so silence stays at zero
+	step, lowest, highest := 0.0, 0.0, 0.0
+	if b.bitcrusher.Bits > 0 && b.bitcrusher.Bits < maxBitcrusherBits {
+		levels := math.Pow(2, float64(b.bitcrusher.Bits))
+		step = 2 / levels
+		lowest, highest = -levels/2, levels/2-1
+	}
+
+	for i := 0; i < n; i++ {
+		if b.holdCount == 0 {
+			b.held = samples[i]
+			if step > 0 {
+				b.held[0] = min(max(math.Round(b.held[0]/step), lowest), highest) * step
+				b.held[1] = min(max(math.Round(b.held[1]/step), lowest), highest) * step
+			}
+		}
+
+		samples[i] = b.held
+		b.holdCount = (b.holdCount + 1) % downsample
+	}
+
+	return n, ok
+}
+
+// Err returns any error of the wrapped streamer
+func (b *bitcrusherEffect) Err() error {
+	return b.Streamer.Err()
+}
@@ -0,0 +1,67 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// rampStreamer streams a ramp from -1 to 1 over the given number of samples
+func rampStreamer(length int) beep.Streamer {
+	pos := 0
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			value := -1 + 2*float64(pos)/float64(length-1)
+			samples[i] = [2]float64{value, value}
+			pos++
+		}
+		return len(samples), true
+	})
+}
+
+func TestBitcrusherLevels(t *testing.T) {
+	const length = 10000
+
+	for _, bits := range []int{1, 2, 4, 8} {
+		samples := make([][2]float64, length)
+		newBitcrusherEffect(rampStreamer(length), Bitcrusher{Bits: bits}).Stream(samples)
+
+		levels := map[float64]bool{}
+		for _, sample := range samples {
+			levels[sample[0]] = true
+		}
+		if want := 1 << bits; len(levels) != want {
+			t.Errorf("Expected %d bits to give %d levels, got %d", bits, want, len(levels))
+		}
+		if bits > 1 && !levels[0] {
+			t.Errorf("Expected %d bits to keep silence at zero", bits)
+		}
+	}
+}
+
+func TestBitcrusherDownsample(t *testing.T) {
+	samples := make([][2]float64, 12)
+	newBitcrusherEffect(rampStreamer(len(samples)), Bitcrusher{Downsample: 4}).Stream(samples)
+
+	for i, sample := range samples {
+		if held := samples[i/4*4]; sample != held {
+			t.Errorf("Expected sample %d to hold %v, got %v", i, held, sample)
+		}
+	}
+	if samples[0] == samples[4] {
+		t.Error("Expected the held sample to change every 4 samples")
+	}
+}
+
+func TestBitcrusherInactive(t *testing.T) {
+	for _, bitcrusher := range []Bitcrusher{{}, {Bits: maxBitcrusherBits}, {Downsample: 1}} {
+		samples := make([][2]float64, 100)
+		newBitcrusherEffect(rampStreamer(len(samples)), bitcrusher).Stream(samples)
+		for i, sample := range samples {
+			if want := -1 + 2*float64(i)/99; math.Abs(sample[0]-want) > 1e-12 {
+				t.Fatalf("Expected %+v to pass the signal untouched, got %f at %d", bitcrusher, sample[0], i)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package audio
+
+import (
+	"github.com/gopxl/beep/v2"
+)
+
+// Bus holds the settings of a submix bus that groups the channels of several tracks
+type Bus struct {
+	Name    string
+	Inserts []Insert // effect chain shared by all channels routed into the bus
+	Gain    float64  // fader position in dB, 0 = unity
+	Mute    bool
+	Ducker  Ducker // sidechain ducking keyed by a track, applied post fader
+}
+
+// NewBus creates a bus at unity gain without effects
+func NewBus(name string) Bus {
+	return Bus{Name: name}
+}
+
+// busStrip is the runtime state of a submix bus
+type busStrip struct {
+	bus     Bus
+	input   [][2]float64 // summed output of the channels routed into the bus
+	inserts *insertChain
+	ducker  duckerState
+	buffer  [][2]float64
+	peak    float64 // highest post fader level since the last meter read
+}
+
+func newBusStrip(sampleRate beep.SampleRate, bus Bus) *busStrip {
+	b := &busStrip{bus: bus, ducker: newDuckerState(sampleRate)}
+	b.inserts = newInsertChain(beep.StreamerFunc(b.streamInput), sampleRate, bus.Inserts)
+	return b
+}
+
+// setBus updates the bus settings without interrupting its effects
+func (b *busStrip) setBus(bus Bus) {
+	b.bus = bus
+	b.inserts.setInserts(bus.Inserts)
+}
+
+// clearInput prepares the input buffer for the next channels to be summed into
+func (b *busStrip) clearInput(length int) {
+	b.input = growBuffer(b.input, length)
+	clear(b.input)
+}
+
+// stream runs the summed input through the effect chain, fader and ducker into the bus buffer
+func (b *busStrip) stream(length int, key []float64) {
+	b.buffer = growBuffer(b.buffer, length)
+	b.inserts.Stream(b.buffer)
+
+	if b.bus.Mute || b.bus.Gain <= MinLevel {
+		clear(b.buffer)
+		return
+	}
+
+	gain := levelToGain(b.bus.Gain)
+	for i := range b.buffer {
+		b.buffer[i][0] *= gain
+		b.buffer[i][1] *= gain
+	}
+
+	if b.bus.Ducker.Active() && key != nil {
+		b.ducker.process(b.bus.Ducker, key, b.buffer)
+	}
+
+	b.peak = max(b.peak, peakLevel(b.buffer))
+}
+
+// streamInput provides the summed channels as input of the effect chain
+func (b *busStrip) streamInput(samples [][2]float64) (n int, ok bool) {
+	return copy(samples, b.input), true
+}
@@ -0,0 +1,124 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// MinLevel is the lowest level in dB for faders and level controls, silent at or below it
+const MinLevel = -60
+
+// Channel holds the mixer channel settings of a track
+type Channel struct {
+	Inserts    []Insert // effect chain applied before the bitcrusher
+	Bitcrusher Bitcrusher
+	Gain       float64 // fader position in dB, 0 = unity
+	Pan        float64 // -1.0 = full left, 0.0 = center, 1.0 = full right
+	Mute       bool
+	Solo       bool
+	ReverbSend float64 // post fader amount sent to the reverb bus [0..1]
+	Bus        int     // number of the bus the channel is routed into, 0 routes to the master
+	Ducker     Ducker  // sidechain ducking keyed by another track, applied post fader
+	Voicing    Voicing // voice allocation of the track's instrument
+}
+
+// PanGains returns the constant-power left and right gains for the pan position
+func (c Channel) PanGains() (left, right float64) {
+	return panGains(c.Pan)
+}
+
+// panGains returns the constant-power left and right gains for a pan position [-1..1]
+func panGains(pan float64) (left, right float64) {
+	angle := (min(max(pan, -1), 1) + 1) * math.Pi / 4
+	return math.Cos(angle), math.Sin(angle)
+}
+
+// channelStrip is the runtime state of a track's mixer channel
+type channelStrip struct {
+	channel Channel
+	voices  *voiceAllocator
+	inserts *insertChain
+	crusher *bitcrusherEffect
+	ducker  duckerState
+	buffer  [][2]float64
+	key     []float64 // pre fader peak level of every sample, used as sidechain key by other channels
+	peak    float64   // highest post fader level since the last meter read
+}
+
+func newChannelStrip(sampleRate beep.SampleRate, channel Channel) *channelStrip {
+	voices := newVoiceAllocator(sampleRate, channel.Voicing)
+
+	inserts := newInsertChain(voices, sampleRate, channel.Inserts)
+
+	return &channelStrip{
+		channel: channel,
+		voices:  voices,
+		inserts: inserts,
+		crusher: newBitcrusherEffect(inserts, channel.Bitcrusher),
+		ducker:  newDuckerState(sampleRate),
+	}
+}
+
+// setChannel updates the channel settings without interrupting its voices
+func (c *channelStrip) setChannel(channel Channel) {
+	c.channel = channel
+	c.voices.voicing = channel.Voicing
+	c.inserts.setInserts(channel.Inserts)
+	c.crusher.bitcrusher = channel.Bitcrusher
+}
+
+// stream renders the next samples of the channel into its buffer, applying fader and pan.
+// The key is recorded before muting, so a muted track can still trigger duckers.
+func (c *channelStrip) stream(length int, silent bool) {
+	c.buffer = growBuffer(c.buffer, length)
+	c.crusher.Stream(c.buffer)
+
+	if cap(c.key) < length {
+		c.key = make([]float64, length)
+	}
+	c.key = c.key[:length]
+	for i := range c.buffer {
+		c.key[i] = max(math.Abs(c.buffer[i][0]), math.Abs(c.buffer[i][1]))
+	}
+
+	if silent || c.channel.Gain <= MinLevel {
+		clear(c.buffer)
+		return
+	}
+
+	gain := levelToGain(c.channel.Gain)
+	left, right := c.channel.PanGains()
+	left, right = left*gain, right*gain
+
+	for i := range c.buffer {
+		c.buffer[i][0] *= left
+		c.buffer[i][1] *= right
+	}
+}
+
+// duck applies the sidechain ducker with the given key and tracks the peak level for the meter
+func (c *channelStrip) duck(key []float64) {
+	if c.channel.Ducker.Active() && key != nil {
+		c.ducker.process(c.channel.Ducker, key, c.buffer)
+	}
+
+	c.peak = max(c.peak, peakLevel(c.buffer))
+}
+
+// peakLevel returns the highest absolute sample value of both channels
+func peakLevel(samples [][2]float64) float64 {
+	peak := 0.0
+	for _, sample := range samples {
+		peak = max(peak, math.Abs(sample[0]), math.Abs(sample[1]))
+	}
+	return peak
+}
+
+// levelToGain converts a level in dB to a linear gain, levels at or below MinLevel are silent
+func levelToGain(level float64) float64 {
+	if level <= MinLevel {
+		return 0
+	}
+	return decibelsToGain(level)
+}
@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestChannelPanGains(t *testing.T) {
+	tests := []struct {
+		pan         float64
+		left, right float64
+	}{
+		{-1, 1, 0},
+		{0, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{1, 0, 1},
+	}
+
+	for _, tt := range tests {
+		left, right := Channel{Pan: tt.pan}.PanGains()
+		if math.Abs(left-tt.left) > 1e-9 || math.Abs(right-tt.right) > 1e-9 {
+			t.Errorf("Expected pan %.0f to give %f/%f, got %f/%f", tt.pan, tt.left, tt.right, left, right)
+		}
+		// Constant power keeps the perceived level at every position
+		if power := left*left + right*right; math.Abs(power-1) > 1e-9 {
+			t.Errorf("Expected constant power at pan %.0f, got %f", tt.pan, power)
+		}
+	}
+}
+
+// playAll starts a note on every track of the engine and returns the peak output and channel levels
+func playAll(engine *Engine, tracks int) (peak float64, levels []float64) {
+	for track := range tracks {
+		engine.Play(track, testInstrument{}, NewNote(BaseC, Octave4), time.Second)
+	}
+
+	samples := make([][2]float64, 4096)
+	engine.Stream(samples)
+	return peakLevel(samples), engine.Levels()
+}
+
+func TestChannelMute(t *testing.T) {
+	engine := NewEngine(testSampleRate, time.Second, []Channel{{Mute: true}}, nil, Master{})
+	if peak, levels := playAll(engine, 1); peak != 0 || levels[0] != 0 {
+		t.Errorf("Expected a muted channel to be silent, got peak %f, level %f", peak, levels[0])
+	}
+
+	engine = NewEngine(testSampleRate, time.Second, []Channel{{}}, nil, Master{})
+	if peak, _ := playAll(engine, 1); peak == 0 {
+		t.Error("Expected an unmuted channel to be audible")
+	}
+}
+
+func TestChannelSolo(t *testing.T) {
+	engine := NewEngine(testSampleRate, time.Second, []Channel{{}, {Solo: true}, {}, {Solo: true}}, nil, Master{})
+
+	_, levels := playAll(engine, 4)
+	for track, level := range levels {
+		soloed := track == 1 || track == 3
+		if soloed != (level > 0) {
+			t.Errorf("Expected track %d to be audible only when soloed, got level %f", track+1, level)
+		}
+	}
+}
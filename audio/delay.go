@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// maxDelayTime bounds the delay line, longer delay times are clamped
+const maxDelayTime = 2 * time.Second
+
+// Delay holds the settings of an echo effect
+type Delay struct {
+	Time     float64 // delay time in milliseconds, used when Rows is 0
+	Rows     int     // delay time in tracker rows, 0 = use Time
+	Feedback float64 // amount of the echo fed back into the delay line [0..1)
+	Mix      float64 // 0.0 = dry only, 1.0 = echo only
+	PingPong bool    // alternate echoes between the left and right channel
+}
+
+// Duration returns the delay time, rows are converted using the row duration
+func (d Delay) Duration(rowDuration time.Duration) time.Duration {
+	if d.Rows > 0 {
+		return time.Duration(d.Rows) * rowDuration
+	}
+
+	return time.Duration(d.Time * float64(time.Millisecond))
+}
+
+// delayEffect implements beep.Streamer for a feedback delay line
+type delayEffect struct {
+	Streamer    beep.Streamer
+	sampleRate  beep.SampleRate
+	rowDuration time.Duration
+	delay       Delay
+	buffer      [][2]float64
+	pos         int
+}
+
+func newDelayEffect(streamer beep.Streamer, sampleRate beep.SampleRate, rowDuration time.Duration, delay Delay) *delayEffect {
+	return &delayEffect{
+		Streamer:    streamer,
+		sampleRate:  sampleRate,
+		rowDuration: rowDuration,
+		delay:       delay,
+		buffer:      make([][2]float64, sampleRate.N(maxDelayTime)),
+	}
+}
+
+// Stream fills the samples buffer with the dry signal mixed with its echoes
+func (d *delayEffect) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = d.Streamer.Stream(samples)
+
+	length := len(d.buffer)
+	delaySamples := min(max(d.sampleRate.N(d.delay.Duration(d.rowDuration)), 1), length-1)
+	feedback := min(max(d.delay.Feedback, 0), 0.95)
+	mix := min(max(d.delay.Mix, 0), 1)
+
+	for i := 0; i < n; i++ {
+		dry := samples[i]
+		echo := d.buffer[(d.pos-delaySamples+length)%length]
+
+		if d.delay.PingPong {
+			// Input enters on the left, each repeat crosses over to the other side
+			d.buffer[d.pos][0] = (dry[0]+dry[1])/2 + echo[1]*feedback
+			d.buffer[d.pos][1] = echo[0] * feedback
+		} else {
+			d.buffer[d.pos][0] = dry[0] + echo[0]*feedback
+			d.buffer[d.pos][1] = dry[1] + echo[1]*feedback
+		}
+
+		samples[i][0] = dry[0]*(1-mix) + echo[0]*mix
+		samples[i][1] = dry[1]*(1-mix) + echo[1]*mix
+
+		d.pos = (d.pos + 1) % length
+	}
+
+	return n, ok
+}
+
+// Err returns any error of the wrapped streamer
+func (d *delayEffect) Err() error {
+	return d.Streamer.Err()
+}
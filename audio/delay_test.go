@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// impulseStreamer streams a full scale sample on both channels followed by silence
+func impulseStreamer() beep.Streamer {
+	started := false
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		clear(samples)
+		if !started && len(samples) > 0 {
+			samples[0] = [2]float64{1, 1}
+			started = true
+		}
+		return len(samples), true
+	})
+}
+
+// impulseResponse streams the impulse through the effect created by wrap
+func impulseResponse(length int, wrap func(beep.Streamer) beep.Streamer) [][2]float64 {
+	samples := make([][2]float64, length)
+	wrap(impulseStreamer()).Stream(samples)
+	return samples
+}
+
+// expectTaps fails unless the response is silent apart from the given taps
+func expectTaps(t *testing.T, response [][2]float64, taps map[int][2]float64) {
+	t.Helper()
+	for i, sample := range response {
+		want := taps[i]
+		if math.Abs(sample[0]-want[0]) > 1e-9 || math.Abs(sample[1]-want[1]) > 1e-9 {
+			t.Errorf("Expected %v at sample %d, got %v", want, i, sample)
+		}
+	}
+}
+
+func TestDelayTime(t *testing.T) {
+	tap := testSampleRate.N(10 * time.Millisecond)
+	response := impulseResponse(3*tap, func(s beep.Streamer) beep.Streamer {
+		return newDelayEffect(s, testSampleRate, time.Second, Delay{Time: 10, Mix: 0.5})
+	})
+
+	// Half of the dry impulse, then half of a single echo without feedback
+	expectTaps(t, response, map[int][2]float64{0: {0.5, 0.5}, tap: {0.5, 0.5}})
+}
+
+func TestDelayRows(t *testing.T) {
+	// Two rows of 5ms land on the same tap as 10ms, Time is ignored
+	rowDuration := 5 * time.Millisecond
+	tap := testSampleRate.N(2 * rowDuration)
+	response := impulseResponse(3*tap, func(s beep.Streamer) beep.Streamer {
+		return newDelayEffect(s, testSampleRate, rowDuration, Delay{Time: 500, Rows: 2, Mix: 1})
+	})
+
+	expectTaps(t, response, map[int][2]float64{tap: {1, 1}})
+}
+
+func TestDelayFeedback(t *testing.T) {
+	tap := testSampleRate.N(10 * time.Millisecond)
+	response := impulseResponse(4*tap, func(s beep.Streamer) beep.Streamer {
+		return newDelayEffect(s, testSampleRate, time.Second, Delay{Time: 10, Feedback: 0.5, Mix: 1})
+	})
+
+	expectTaps(t, response, map[int][2]float64{tap: {1, 1}, 2 * tap: {0.5, 0.5}, 3 * tap: {0.25, 0.25}})
+}
+
+func TestDelayPingPong(t *testing.T) {
+	tap := testSampleRate.N(10 * time.Millisecond)
+	response := impulseResponse(4*tap+1, func(s beep.Streamer) beep.Streamer {
+		return newDelayEffect(s, testSampleRate, time.Second, Delay{Time: 10, Feedback: 0.5, Mix: 1, PingPong: true})
+	})
+
+	// The echoes start on the left and alternate sides
+	expectTaps(t, response, map[int][2]float64{tap: {1, 0}, 2 * tap: {0, 0.5}, 3 * tap: {0.25, 0}, 4 * tap: {0, 0.125}})
+}
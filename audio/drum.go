@@ -0,0 +1,197 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// InstrumentType selects the sound source playing the notes of a track
+type InstrumentType string
+
+const (
+	SynthInstrument InstrumentType = "synth" // two oscillator synth, also used for the zero value
+	DrumInstrument  InstrumentType = "drum"
+	SfxInstrument   InstrumentType = "sfx" // generated sound effect
+)
+
+// InstrumentTypes lists all instrument types in selection order
+var InstrumentTypes = []InstrumentType{SynthInstrument, DrumInstrument, SfxInstrument}
+
+// DrumPreset names the drum sound the settings of a drum voice started from
+type DrumPreset string
+
+const (
+	DrumKick  DrumPreset = "kick"
+	DrumSnare DrumPreset = "snare"
+	DrumHat   DrumPreset = "hat"
+	DrumTom   DrumPreset = "tom"
+	DrumClap  DrumPreset = "clap"
+)
+
+// DrumPresets lists all drum presets in selection order
+var DrumPresets = []DrumPreset{DrumKick, DrumSnare, DrumHat, DrumTom, DrumClap}
+
+const (
+	drumClickTime    = 0.5 // click decay time constant in ms
+	drumBurstSpacing = 10  // time between the noise bursts of a clap in ms
+	drumBurstDecay   = 2   // decay time constant of a single clap burst in ms
+	drumMaxColor     = 8000.0
+	drumSilence      = 5 // time constants until a decaying layer is treated as silent (-43 dB)
+)
+
+// Drum holds the settings of a drum voice made of a pitch swept body, a noise layer and a click.
+// Pitches are played at C-4, other notes transpose the body relative to it.
+type Drum struct {
+	Preset DrumPreset
+	Pitch  float64 // body frequency in Hz at the start of the sweep
+	End    float64 // body frequency in Hz at the end of the sweep
+	Sweep  float64 // pitch sweep time in ms
+	Decay  float64 // body decay time in ms
+	Noise  float64 // noise decay time in ms
+	Tone   float64 // mix of body and noise, 0.0 = noise only, 1.0 = body only
+	Color  float64 // noise brightness, 0.0 = full band, 1.0 = high frequencies only
+	Click  float64 // level of the transient click at the start, 0.0..1.0
+	Bursts int     // noise bursts, more than one gives a clap
+}
+
+// NewDrum creates the drum settings of a preset, unknown presets give a kick
+func NewDrum(preset DrumPreset) Drum {
+	switch preset {
+	case DrumSnare:
+		return Drum{Preset: preset, Pitch: 240, End: 180, Sweep: 30, Decay: 150, Noise: 220, Tone: 0.4, Color: 0.4, Click: 0.3, Bursts: 1}
+	case DrumHat:
+		return Drum{Preset: preset, Pitch: 400, End: 400, Sweep: 0, Decay: 10, Noise: 70, Tone: 0, Color: 0.9, Click: 0.1, Bursts: 1}
+	case DrumTom:
+		return Drum{Preset: preset, Pitch: 200, End: 110, Sweep: 150, Decay: 350, Noise: 40, Tone: 0.85, Color: 0.2, Click: 0.3, Bursts: 1}
+	case DrumClap:
+		return Drum{Preset: preset, Pitch: 1000, End: 1000, Sweep: 0, Decay: 5, Noise: 250, Tone: 0, Color: 0.6, Click: 0, Bursts: 4}
+	}
+	return Drum{Preset: DrumKick, Pitch: 150, End: 45, Sweep: 60, Decay: 400, Noise: 20, Tone: 0.9, Color: 0, Click: 0.5, Bursts: 1}
+}
+
+// DrumSynth implements Instrument playing one shot drum voices
+type DrumSynth struct {
+	sampleRate beep.SampleRate
+	drum       Drum
+	tuning     Tuning
+}
+
+// NewDrumSynth creates a drum instrument
+func NewDrumSynth(sampleRate beep.SampleRate, drum Drum, tuning Tuning) *DrumSynth {
+	return &DrumSynth{
+		sampleRate: sampleRate,
+		drum:       drum,
+		tuning:     tuning,
+	}
+}
+
+// Voice implements Instrument. Drums are one shots that ring out independent of the note
+// duration, legato is ignored as every hit restarts the sound.
+func (s *DrumSynth) Voice(note Note, d time.Duration, legato bool) beep.Streamer {
+	ratio := 1.0
+	if middleC := s.tuning.Frequency(NewNote(BaseC, Octave4)); middleC > 0 && !IsOff(note) {
+		ratio = s.tuning.Frequency(note) / middleC
+	}
+
+	return newDrumVoice(s.sampleRate, s.drum, ratio)
+}
+
+// drumVoice implements beep.Streamer rendering a single drum hit
+type drumVoice struct {
+	drum       Drum
+	sampleRate float64
+	ratio      float64 // transposition of the body pitch
+	pos        int
+	length     int
+	phase      float64
+	lastNoise  float64
+	highpass   float64
+	coef       float64 // high-pass filter coefficient of the noise
+}
+
+func newDrumVoice(sampleRate beep.SampleRate, drum Drum, ratio float64) *drumVoice {
+	drum.Bursts = max(drum.Bursts, 1)
+	bursts := float64(drum.Bursts-1) * drumBurstSpacing
+
+	// The hit lasts until its longest layer has faded out
+	length := max(drum.Decay, bursts+drum.Noise, drumClickTime*drumSilence)
+
+	coef := 1.0
+	if drum.Color > 0 {
+		rc := 1 / (2 * math.Pi * drum.Color * drumMaxColor)
+		coef = rc / (rc + 1/float64(sampleRate))
+	}
+
+	return &drumVoice{
+		drum:       drum,
+		sampleRate: float64(sampleRate),
+		ratio:      ratio,
+		length:     sampleRate.N(time.Duration(length * float64(time.Millisecond))),
+		coef:       coef,
+	}
+}
+
+// decay returns the gain of an exponential decay reaching silence after the given time in ms
+func decay(t, ms float64) float64 {
+	if ms <= 0 {
+		return 0
+	}
+	return math.Exp(-t * drumSilence / ms)
+}
+
+// Stream fills the samples buffer with the drum hit
+func (v *drumVoice) Stream(samples [][2]float64) (n int, ok bool) {
+	if v.pos >= v.length {
+		return 0, false
+	}
+
+	drum := v.drum
+	bursts := float64(drum.Bursts-1) * drumBurstSpacing
+
+	for n < len(samples) && v.pos < v.length {
+		t := float64(v.pos) * 1000 / v.sampleRate // ms
+
+		// Body sweeping exponentially from the start to the end pitch
+		frequency := drum.End
+		if drum.Sweep > 0 {
+			frequency += (drum.Pitch - drum.End) * decay(t, drum.Sweep)
+		}
+		body := math.Sin(2*math.Pi*v.phase) * decay(t, drum.Decay)
+		v.phase += frequency * v.ratio / v.sampleRate
+		v.phase -= math.Floor(v.phase)
+
+		// Noise through a one pole high-pass, claps retrigger it in short bursts
+		white := rand.Float64()*2 - 1
+		v.highpass = v.coef * (v.highpass + white - v.lastNoise)
+		v.lastNoise = white
+		if drum.Color <= 0 {
+			v.highpass = white
+		}
+
+		noiseGain := decay(t-bursts, drum.Noise)
+		if t < bursts {
+			noiseGain = math.Exp(-math.Mod(t, drumBurstSpacing) / drumBurstDecay)
+		}
+		noise := v.highpass * noiseGain
+
+		click := drum.Click * math.Exp(-t/drumClickTime)
+
+		tone := min(max(drum.Tone, 0), 1)
+		sample := body*tone + noise*(1-tone) + click
+
+		samples[n][0] = sample
+		samples[n][1] = sample
+		n++
+		v.pos++
+	}
+
+	return n, true
+}
+
+// Err returns any error that occurred during streaming
+func (v *drumVoice) Err() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const testSampleRate = beep.SampleRate(44100)
+
+// streamAll streams until the streamer ends, failing after limit samples
+func streamAll(t *testing.T, streamer beep.Streamer, limit int) [][2]float64 {
+	t.Helper()
+	var streamed [][2]float64
+	buffer := make([][2]float64, 512)
+	for {
+		n, ok := streamer.Stream(buffer)
+		streamed = append(streamed, buffer[:n]...)
+		if !ok {
+			break
+		}
+		if len(streamed) > limit {
+			t.Fatalf("Expected the voice to end after %d samples", limit)
+		}
+	}
+
+	if n, ok := streamer.Stream(buffer); n != 0 || ok {
+		t.Errorf("Expected an ended voice to stay ended, got %d samples (%v)", n, ok)
+	}
+	return streamed
+}
+
+// expectAudible fails unless all samples are finite and some are not silent
+func expectAudible(t *testing.T, samples [][2]float64) {
+	t.Helper()
+	peak := 0.0
+	for i, sample := range samples {
+		for _, value := range sample {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				t.Fatalf("Expected finite samples, got %f at %d", value, i)
+			}
+			peak = max(peak, math.Abs(value))
+		}
+	}
+	if peak < 0.01 {
+		t.Errorf("Expected an audible voice, peak is %f", peak)
+	}
+}
+
+func TestDrumVoices(t *testing.T) {
+	for _, preset := range DrumPresets {
+		for _, ratio := range []float64{0.5, 1, 2} {
+			voice := newDrumVoice(testSampleRate, NewDrum(preset), ratio)
+			samples := streamAll(t, voice, voice.length)
+			if len(samples) != voice.length {
+				t.Errorf("Expected %s to last %d samples, got %d", preset, voice.length, len(samples))
+			}
+			expectAudible(t, samples)
+		}
+	}
+}
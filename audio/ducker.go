@@ -0,0 +1,86 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// duckerKeyDecay is how fast the detected key level falls after a peak
+const duckerKeyDecay = 10 * time.Millisecond
+
+// Ducker holds the settings of a sidechain ducker that lowers a channel or bus
+// while the voices of another track play, e.g. bass ducking under the kick
+type Ducker struct {
+	Key       int     // number of the track whose voices trigger the ducking, 0 disables the ducker
+	Threshold float64 // key level in dB above which ducking starts
+	Amount    float64 // gain reduction in dB while the key is above the threshold
+	Attack    float64 // time in ms to reach the full reduction
+	Release   float64 // time in ms to recover after the key falls below the threshold
+}
+
+// NewDucker creates a ducker keyed by the given track number with typical pumping settings
+func NewDucker(key int) Ducker {
+	return Ducker{Key: key, Threshold: -24, Amount: 12, Attack: 5, Release: 150}
+}
+
+// Active reports whether the ducker has a key and reduces the gain
+func (d Ducker) Active() bool {
+	return d.Key > 0 && d.Amount > 0
+}
+
+// duckerState is the runtime state of a ducker
+type duckerState struct {
+	sampleRate beep.SampleRate
+	keyLevel   float64 // peak follower of the key signal
+	gain       float64
+}
+
+func newDuckerState(sampleRate beep.SampleRate) duckerState {
+	return duckerState{sampleRate: sampleRate, gain: 1}
+}
+
+// process applies the gain reduction to the samples, key holds the peak level of every key sample
+func (s *duckerState) process(ducker Ducker, key []float64, samples [][2]float64) {
+	threshold := decibelsToGain(ducker.Threshold)
+	reduced := decibelsToGain(-max(ducker.Amount, 0))
+	attack := s.coefficient(ducker.Attack)
+	release := s.coefficient(ducker.Release)
+	keyDecay := s.coefficient(float64(duckerKeyDecay.Milliseconds()))
+
+	for i := range samples {
+		level := 0.0
+		if i < len(key) {
+			level = key[i]
+		}
+
+		if level > s.keyLevel {
+			s.keyLevel = level
+		} else {
+			s.keyLevel += (level - s.keyLevel) * keyDecay
+		}
+
+		target := 1.0
+		if s.keyLevel > threshold {
+			target = reduced
+		}
+
+		if target < s.gain {
+			s.gain += (target - s.gain) * attack
+		} else {
+			s.gain += (target - s.gain) * release
+		}
+
+		samples[i][0] *= s.gain
+		samples[i][1] *= s.gain
+	}
+}
+
+// coefficient returns the one pole smoothing coefficient for a time constant in ms
+func (s *duckerState) coefficient(ms float64) float64 {
+	if ms <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-1000/(ms*float64(s.sampleRate)))
+}
@@ -0,0 +1,82 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// keyLevels streams the key and returns its peak level of every sample, as channels record it
+func keyLevels(key beep.Streamer, length int) []float64 {
+	samples := make([][2]float64, length)
+	n, _ := key.Stream(samples)
+
+	levels := make([]float64, length)
+	for i := range n {
+		levels[i] = max(math.Abs(samples[i][0]), math.Abs(samples[i][1]))
+	}
+	return levels
+}
+
+func TestDucker(t *testing.T) {
+	ducker := NewDucker(1)
+	ms := func(ms int) int { return testSampleRate.N(time.Duration(ms) * time.Millisecond) }
+
+	// A kick like key at full scale for 100ms followed by silence
+	kick := beep.Take(ms(100), constantStreamer)
+	key := keyLevels(beep.Seq(kick, beep.Silence(-1)), ms(1200))
+
+	samples := make([][2]float64, len(key))
+	for i := range samples {
+		samples[i] = [2]float64{1, 1}
+	}
+	state := newDuckerState(testSampleRate)
+	state.process(ducker, key, samples)
+
+	gain := func(at int) float64 { return samples[at][0] }
+	reduced := decibelsToGain(-ducker.Amount)
+
+	// Full reduction within the attack
+	if got := gain(ms(50)); math.Abs(got-reduced) > 0.01 {
+		t.Errorf("Expected gain %f while the key plays, got %f", reduced, got)
+	}
+
+	// The key falls below the threshold after its level decays, then the gain recovers
+	// with the release time constant
+	below := ms(100) + testSampleRate.N(time.Duration(float64(duckerKeyDecay)*math.Log(1/decibelsToGain(ducker.Threshold))))
+	release := float64(ms(int(ducker.Release)))
+	for _, after := range []float64{0.5, 1, 2} {
+		at := below + int(after*release)
+		want := 1 - (1-reduced)*math.Exp(-after)
+		if got := gain(at); math.Abs(got-want) > 0.02 {
+			t.Errorf("Expected gain %f at %.1f release times, got %f", want, after, got)
+		}
+	}
+	if got := gain(len(samples) - 1); got < 0.99 {
+		t.Errorf("Expected the gain to recover, got %f", got)
+	}
+}
+
+func TestDuckerBelowThreshold(t *testing.T) {
+	ducker := NewDucker(1)
+
+	// A key 6dB below the threshold leaves the signal untouched
+	key := make([]float64, 1000)
+	for i := range key {
+		key[i] = decibelsToGain(ducker.Threshold - 6)
+	}
+	samples := make([][2]float64, len(key))
+	for i := range samples {
+		samples[i] = [2]float64{1, 1}
+	}
+
+	state := newDuckerState(testSampleRate)
+	state.process(ducker, key, samples)
+	for i, sample := range samples {
+		if sample[0] != 1 {
+			t.Fatalf("Expected unity gain, got %f at %d", sample[0], i)
+		}
+	}
+}
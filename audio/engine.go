@@ -0,0 +1,271 @@
+package audio
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/effects"
+)
+
+// Master holds the settings of the master bus
+type Master struct {
+	Delay      Delay
+	Bitcrusher Bitcrusher
+	Reverb     Reverb // reverb on the send bus fed by the track channels
+	Limiter    Limiter
+}
+
+// Engine mixes the voices of all tracks through their channels, optionally grouped
+// on submix buses, and runs the mixed output through the master bus. The same engine is used for live playback and
+// offline rendering. During live playback the engine is streamed by the speaker,
+// so callers must hold speaker.Lock while playing voices or changing settings.
+type Engine struct {
+	sampleRate beep.SampleRate
+	channels   []*channelStrip
+	buses      []*busStrip
+	sends      [][2]float64 // summed reverb sends of all channels
+	reverb     *reverbEffect
+	returns    [][2]float64 // reverb bus output
+	delay      *delayEffect
+	crusher    *bitcrusherEffect
+	limiter    *limiterEffect
+	volume     *effects.Volume
+	clipped    bool // output exceeded full scale since the last status query
+}
+
+// NewEngine creates a new engine with one channel per track, the given submix buses and master bus settings
+func NewEngine(sampleRate beep.SampleRate, rowDuration time.Duration, channels []Channel, buses []Bus, master Master) *Engine {
+	e := &Engine{sampleRate: sampleRate}
+	e.SetChannels(channels)
+	e.SetBuses(buses)
+
+	e.reverb = newReverbEffect(beep.StreamerFunc(e.streamSends), sampleRate, master.Reverb, true)
+	e.delay = newDelayEffect(beep.StreamerFunc(e.mixChannels), sampleRate, rowDuration, master.Delay)
+	e.crusher = newBitcrusherEffect(e.delay, master.Bitcrusher)
+	e.limiter = newLimiterEffect(e.crusher, sampleRate, master.Limiter)
+	e.volume = &effects.Volume{Streamer: e.limiter, Base: 2}
+
+	return e
+}
+
+// Play starts a voice of the instrument on the channel of the track, voices are removed
+// once drained or stolen when the channel runs out of polyphony
+func (e *Engine) Play(track int, instrument Instrument, note Note, d time.Duration) {
+	if track < 0 || track >= len(e.channels) {
+		return
+	}
+
+	e.channels[track].voices.noteOn(instrument, note, d)
+}
+
+// Stop fades out the voices of all channels, effect tails keep ringing
+func (e *Engine) Stop() {
+	for _, ch := range e.channels {
+		ch.voices.stop()
+	}
+}
+
+// SetChannels updates the settings of all channels, adding or removing channels to match
+func (e *Engine) SetChannels(channels []Channel) {
+	for i, channel := range channels {
+		if i < len(e.channels) {
+			e.SetChannel(i, channel)
+		} else {
+			e.channels = append(e.channels, newChannelStrip(e.sampleRate, channel))
+		}
+	}
+
+	e.channels = e.channels[:len(channels)]
+}
+
+// SetChannel updates the settings of a track's channel without interrupting playback
+func (e *Engine) SetChannel(track int, channel Channel) {
+	if track < 0 || track >= len(e.channels) {
+		return
+	}
+
+	e.channels[track].setChannel(channel)
+}
+
+// SetBuses updates the settings of all submix buses, adding or removing buses to match
+func (e *Engine) SetBuses(buses []Bus) {
+	for i, bus := range buses {
+		if i < len(e.buses) {
+			e.buses[i].setBus(bus)
+		} else {
+			e.buses = append(e.buses, newBusStrip(e.sampleRate, bus))
+		}
+	}
+
+	e.buses = e.buses[:len(buses)]
+}
+
+// SetBus updates the settings of a submix bus without interrupting playback
+func (e *Engine) SetBus(idx int, bus Bus) {
+	if idx < 0 || idx >= len(e.buses) {
+		return
+	}
+
+	e.buses[idx].setBus(bus)
+}
+
+// SetMaster updates the master bus settings without interrupting playback
+func (e *Engine) SetMaster(master Master) {
+	e.delay.delay = master.Delay
+	e.crusher.bitcrusher = master.Bitcrusher
+	e.reverb.reverb = master.Reverb
+	e.limiter.limiter = master.Limiter
+}
+
+// SetRowDuration updates the row duration the tempo synced effects follow, e.g. after a tempo change
+func (e *Engine) SetRowDuration(rowDuration time.Duration) {
+	e.delay.rowDuration = rowDuration
+}
+
+// SetVolume sets the output volume (0.0 to 1.0)
+func (e *Engine) SetVolume(volume float64) {
+	e.volume.Volume = volumeToDecibels(volume)
+	e.volume.Silent = volume == 0
+}
+
+// Stream fills the samples buffer with the master bus output
+func (e *Engine) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = e.volume.Stream(samples)
+
+	for i := 0; i < n; i++ {
+		if math.Abs(samples[i][0]) > 1 || math.Abs(samples[i][1]) > 1 {
+			e.clipped = true
+			break
+		}
+	}
+
+	return n, ok
+}
+
+// Levels returns the peak level of every channel followed by every bus since the last call
+func (e *Engine) Levels() []float64 {
+	levels := make([]float64, 0, len(e.channels)+len(e.buses))
+	for _, ch := range e.channels {
+		levels = append(levels, ch.peak)
+		ch.peak = 0
+	}
+	for _, bus := range e.buses {
+		levels = append(levels, bus.peak)
+		bus.peak = 0
+	}
+	return levels
+}
+
+// ClipStatus reports whether the limiter reduced the gain and whether the output
+// clipped since the last call
+func (e *Engine) ClipStatus() (limiting, clipped bool) {
+	clipped = e.clipped
+	e.clipped = false
+	return e.limiter.limiting(), clipped
+}
+
+// Err returns any error that occurred during streaming
+func (e *Engine) Err() error {
+	return nil
+}
+
+// mixChannels sums the output of all channels, submix buses and the reverb bus into the samples buffer
+func (e *Engine) mixChannels(samples [][2]float64) (n int, ok bool) {
+	clear(samples)
+
+	e.sends = growBuffer(e.sends, len(samples))
+	clear(e.sends)
+
+	for _, bus := range e.buses {
+		bus.clearInput(len(samples))
+	}
+
+	solo := slices.ContainsFunc(e.channels, func(ch *channelStrip) bool { return ch.channel.Solo })
+
+	// All channels are streamed first, so every sidechain key is available when ducking
+	for _, ch := range e.channels {
+		silent := ch.channel.Mute || (solo && !ch.channel.Solo)
+
+		// A muted bus also silences the reverb sends of its channels
+		if bus := e.bus(ch.channel.Bus); bus != nil {
+			silent = silent || bus.bus.Mute
+		}
+
+		// Muted channels keep streaming so their voices stay in time
+		ch.stream(len(samples), silent)
+	}
+
+	for _, ch := range e.channels {
+		ch.duck(e.key(ch.channel.Ducker.Key))
+
+		output := samples
+		if bus := e.bus(ch.channel.Bus); bus != nil {
+			output = bus.input
+		}
+
+		send := ch.channel.ReverbSend
+		for i := range samples {
+			output[i][0] += ch.buffer[i][0]
+			output[i][1] += ch.buffer[i][1]
+			e.sends[i][0] += ch.buffer[i][0] * send
+			e.sends[i][1] += ch.buffer[i][1] * send
+		}
+	}
+
+	for _, bus := range e.buses {
+		bus.stream(len(samples), e.key(bus.bus.Ducker.Key))
+
+		for i := range samples {
+			samples[i][0] += bus.buffer[i][0]
+			samples[i][1] += bus.buffer[i][1]
+		}
+	}
+
+	e.returns = growBuffer(e.returns, len(samples))
+	e.reverb.Stream(e.returns)
+
+	for i := range samples {
+		samples[i][0] += e.returns[i][0]
+		samples[i][1] += e.returns[i][1]
+	}
+
+	return len(samples), true
+}
+
+// bus returns the submix bus with the given number, nil for the master or unknown buses
+func (e *Engine) bus(number int) *busStrip {
+	if number < 1 || number > len(e.buses) {
+		return nil
+	}
+	return e.buses[number-1]
+}
+
+// key returns the sidechain key of the track with the given number, nil if there is no such track
+func (e *Engine) key(track int) []float64 {
+	if track < 1 || track > len(e.channels) {
+		return nil
+	}
+	return e.channels[track-1].key
+}
+
+// streamSends provides the summed reverb sends as input of the reverb bus
+func (e *Engine) streamSends(samples [][2]float64) (n int, ok bool) {
+	return copy(samples, e.sends), true
+}
+
+// growBuffer returns a buffer of the given length, reusing the existing one if it is large enough
+func growBuffer(buffer [][2]float64, length int) [][2]float64 {
+	if cap(buffer) < length {
+		return make([][2]float64, length)
+	}
+	return buffer[:length]
+}
+
+func volumeToDecibels(volume float64) float64 {
+	if volume <= 0 {
+		return -999
+	}
+	return math.Log2(volume) * 6
+}
@@ -0,0 +1,48 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineBusMute(t *testing.T) {
+	channels := []Channel{{Bus: 1}, {Bus: 1}, {}}
+	buses := []Bus{NewBus("Drums")}
+	engine := NewEngine(testSampleRate, time.Second, channels, buses, Master{})
+
+	_, levels := playAll(engine, 2)
+	if levels[0] == 0 || levels[1] == 0 || levels[3] == 0 {
+		t.Fatalf("Expected the tracks and their bus to be audible, got levels %v", levels)
+	}
+
+	// Muting the bus silences all tracks routed into it
+	buses[0].Mute = true
+	engine = NewEngine(testSampleRate, time.Second, channels, buses, Master{})
+	if peak, levels := playAll(engine, 2); peak != 0 || levels[0] != 0 || levels[1] != 0 || levels[3] != 0 {
+		t.Errorf("Expected the muted bus and its tracks to be silent, got peak %f, levels %v", peak, levels)
+	}
+
+	// Tracks routed to the master keep playing
+	engine.Play(2, testInstrument{}, NewNote(BaseC, Octave4), time.Second)
+	samples := make([][2]float64, 4096)
+	engine.Stream(samples)
+	if levels := engine.Levels(); levels[2] == 0 || peakLevel(samples) == 0 {
+		t.Errorf("Expected the track on the master to be audible, got levels %v", levels)
+	}
+}
+
+func TestEngineBusInserts(t *testing.T) {
+	// The bus insert chain processes the sum of its tracks
+	overdrive := NewInsert(Overdrive)
+	overdrive.Drive, overdrive.Mix = 1, 1
+	buses := []Bus{{Name: "Drive", Inserts: []Insert{overdrive}}}
+
+	clean := NewEngine(testSampleRate, time.Second, []Channel{{}, {}}, nil, Master{})
+	driven := NewEngine(testSampleRate, time.Second, []Channel{{Bus: 1}, {Bus: 1}}, buses, Master{})
+
+	cleanPeak, _ := playAll(clean, 2)
+	drivenPeak, _ := playAll(driven, 2)
+	if drivenPeak == cleanPeak {
+		t.Errorf("Expected the bus overdrive to change the summed tracks, both peak at %f", cleanPeak)
+	}
+}
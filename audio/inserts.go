@@ -0,0 +1,244 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// InsertType represents the type of effect in a track's insert chain
+type InsertType string
+
+const (
+	Overdrive InsertType = "overdrive"
+	Chorus    InsertType = "chorus"
+	Flanger   InsertType = "flanger"
+)
+
+// Insert holds the settings of one effect in a track's insert chain.
+// Overdrive uses Drive and Tone, chorus and flanger use Rate, Depth and Feedback.
+type Insert struct {
+	Type     InsertType
+	Drive    float64 // waveshaper input gain [0..1]
+	Tone     float64 // low pass after the waveshaper, 0.0 = dark, 1.0 = bright
+	Rate     float64 // modulation speed [0..1]
+	Depth    float64 // modulation depth [0..1]
+	Feedback float64 // modulated delay feedback [0..1)
+	Mix      float64 // 0.0 = dry only, 1.0 = effect only
+}
+
+// NewInsert creates an insert with sensible defaults for the effect type
+func NewInsert(insertType InsertType) Insert {
+	switch insertType {
+	case Chorus:
+		return Insert{Type: Chorus, Rate: 0.2, Depth: 0.5, Feedback: 0, Mix: 0.5}
+	case Flanger:
+		return Insert{Type: Flanger, Rate: 0.1, Depth: 0.7, Feedback: 0.6, Mix: 0.5}
+	default:
+		return Insert{Type: Overdrive, Drive: 0.5, Tone: 0.6, Mix: 1}
+	}
+}
+
+// insertEffect is a streamer wrapper whose settings can change while streaming
+type insertEffect interface {
+	beep.Streamer
+	setInsert(insert Insert)
+}
+
+// insertChain implements beep.Streamer for a chain of insert effects
+type insertChain struct {
+	Streamer   beep.Streamer
+	sampleRate beep.SampleRate
+	inserts    []Insert
+	effects    []insertEffect
+}
+
+func newInsertChain(streamer beep.Streamer, sampleRate beep.SampleRate, inserts []Insert) *insertChain {
+	c := &insertChain{Streamer: streamer, sampleRate: sampleRate}
+	c.setInserts(inserts)
+	return c
+}
+
+// setInserts updates the chain, effects are only rebuilt when the effect types change
+func (c *insertChain) setInserts(inserts []Insert) {
+	if sameInsertTypes(c.inserts, inserts) {
+		for i, insert := range inserts {
+			c.effects[i].setInsert(insert)
+		}
+		c.inserts = append(c.inserts[:0], inserts...)
+		return
+	}
+
+	c.inserts = append([]Insert(nil), inserts...)
+	c.effects = nil
+
+	source := c.Streamer
+	for _, insert := range inserts {
+		var effect insertEffect
+		switch insert.Type {
+		case Chorus, Flanger:
+			effect = newModulatedDelay(source, c.sampleRate, insert)
+		default:
+			effect = newOverdrive(source, c.sampleRate, insert)
+		}
+
+		c.effects = append(c.effects, effect)
+		source = effect
+	}
+}
+
+// Stream fills the samples buffer with the output of the last effect in the chain
+func (c *insertChain) Stream(samples [][2]float64) (n int, ok bool) {
+	if len(c.effects) == 0 {
+		return c.Streamer.Stream(samples)
+	}
+
+	return c.effects[len(c.effects)-1].Stream(samples)
+}
+
+// Err returns any error of the wrapped streamer
+func (c *insertChain) Err() error {
+	return c.Streamer.Err()
+}
+
+func sameInsertTypes(a, b []Insert) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Type != b[i].Type {
+			return false
+		}
+	}
+
+	return true
+}
+
+// overdriveEffect implements beep.Streamer for a tanh waveshaper followed by a tone filter
+type overdriveEffect struct {
+	Streamer   beep.Streamer
+	sampleRate beep.SampleRate
+	insert     Insert
+	lowpass    [2]float64
+}
+
+func newOverdrive(streamer beep.Streamer, sampleRate beep.SampleRate, insert Insert) *overdriveEffect {
+	return &overdriveEffect{Streamer: streamer, sampleRate: sampleRate, insert: insert}
+}
+
+func (o *overdriveEffect) setInsert(insert Insert) {
+	o.insert = insert
+}
+
+// Stream fills the samples buffer with the overdriven signal
+func (o *overdriveEffect) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = o.Streamer.Stream(samples)
+
+	gain := 1 + min(max(o.insert.Drive, 0), 1)*20
+	normalize := 1 / math.Tanh(gain)
+	mix := min(max(o.insert.Mix, 0), 1)
+
+	// One pole low pass, tone sweeps the cutoff from 500Hz to 12kHz
+	cutoff := 500 * math.Pow(24, min(max(o.insert.Tone, 0), 1))
+	alpha := 1 - math.Exp(-2*math.Pi*cutoff/float64(o.sampleRate))
+
+	for i := 0; i < n; i++ {
+		for ch := range 2 {
+			dry := samples[i][ch]
+			shaped := math.Tanh(dry*gain) * normalize
+			o.lowpass[ch] += alpha * (shaped - o.lowpass[ch])
+			samples[i][ch] = dry*(1-mix) + o.lowpass[ch]*mix
+		}
+	}
+
+	return n, ok
+}
+
+// Err returns any error of the wrapped streamer
+func (o *overdriveEffect) Err() error {
+	return o.Streamer.Err()
+}
+
+// modulatedDelayEffect implements beep.Streamer for chorus and flanger effects
+type modulatedDelayEffect struct {
+	Streamer   beep.Streamer
+	sampleRate beep.SampleRate
+	insert     Insert
+	buffer     [][2]float64
+	pos        int
+	lfoPhase   float64
+}
+
+const maxModulatedDelay = 30 * time.Millisecond
+
+func newModulatedDelay(streamer beep.Streamer, sampleRate beep.SampleRate, insert Insert) *modulatedDelayEffect {
+	return &modulatedDelayEffect{
+		Streamer:   streamer,
+		sampleRate: sampleRate,
+		insert:     insert,
+		buffer:     make([][2]float64, sampleRate.N(maxModulatedDelay)),
+	}
+}
+
+func (d *modulatedDelayEffect) setInsert(insert Insert) {
+	d.insert = insert
+}
+
+// Stream fills the samples buffer with the chorused or flanged signal
+func (d *modulatedDelayEffect) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = d.Streamer.Stream(samples)
+
+	// Chorus sweeps a long delay, flanger a short one with feedback
+	baseDelay, sweep := 10.0, 10.0
+	if d.insert.Type == Flanger {
+		baseDelay, sweep = 1.0, 5.0
+	}
+
+	msToSamples := float64(d.sampleRate) / 1000
+	rate := 0.05 + min(max(d.insert.Rate, 0), 1)*5 // Hz
+	depth := min(max(d.insert.Depth, 0), 1)
+	feedback := min(max(d.insert.Feedback, 0), 0.9)
+	mix := min(max(d.insert.Mix, 0), 1)
+	length := len(d.buffer)
+
+	for i := 0; i < n; i++ {
+		dry := samples[i]
+		var wet [2]float64
+
+		for ch := range 2 {
+			// Right channel LFO runs a quarter period behind for stereo width
+			lfo := (1 + math.Sin(2*math.Pi*(d.lfoPhase+float64(ch)*0.25))) / 2
+			delay := min((baseDelay+sweep*depth*lfo)*msToSamples, float64(length-2))
+
+			readPos := float64(d.pos) - delay
+			if readPos < 0 {
+				readPos += float64(length)
+			}
+
+			idx := int(readPos)
+			frac := readPos - float64(idx)
+			wet[ch] = d.buffer[idx][ch]*(1-frac) + d.buffer[(idx+1)%length][ch]*frac
+		}
+
+		d.buffer[d.pos][0] = dry[0] + wet[0]*feedback
+		d.buffer[d.pos][1] = dry[1] + wet[1]*feedback
+		d.pos = (d.pos + 1) % length
+
+		samples[i][0] = dry[0]*(1-mix) + wet[0]*mix
+		samples[i][1] = dry[1]*(1-mix) + wet[1]*mix
+
+		d.lfoPhase += rate / float64(d.sampleRate)
+		if d.lfoPhase >= 1 {
+			d.lfoPhase -= 1
+		}
+	}
+
+	return n, ok
+}
+
+// Err returns any error of the wrapped streamer
+func (d *modulatedDelayEffect) Err() error {
+	return d.Streamer.Err()
+}
@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// streamChain streams length samples of the source through an insert chain
+func streamChain(source beep.Streamer, length int, inserts ...Insert) [][2]float64 {
+	samples := make([][2]float64, length)
+	newInsertChain(source, testSampleRate, inserts).Stream(samples)
+	return samples
+}
+
+// quietSine streams a sine at a quarter of full scale
+func quietSine() beep.Streamer {
+	sine := chordStreamer(testSampleRate, 220)
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		n, ok := sine.Stream(samples)
+		for i := range n {
+			samples[i][0] *= 0.25
+			samples[i][1] *= 0.25
+		}
+		return n, ok
+	})
+}
+
+func TestOverdrive(t *testing.T) {
+	length := testSampleRate.N(100 * time.Millisecond)
+	clean := streamChain(quietSine(), length)
+
+	// Full wet drive saturates the quiet sine close to full scale
+	overdrive := NewInsert(Overdrive)
+	overdrive.Drive, overdrive.Tone = 0.8, 1
+	if peak := peakLevel(streamChain(quietSine(), length, overdrive)); peak < 0.8 {
+		t.Errorf("Expected the overdrive to boost the sine from %f, peak is %f", peakLevel(clean), peak)
+	}
+
+	// Without wet signal the sine passes untouched
+	overdrive.Mix = 0
+	for i, sample := range streamChain(quietSine(), length, overdrive) {
+		if math.Abs(sample[0]-clean[i][0]) > 1e-12 {
+			t.Fatalf("Expected the dry overdrive to pass the signal, got %f instead of %f at %d", sample[0], clean[i][0], i)
+		}
+	}
+}
+
+func TestChorus(t *testing.T) {
+	chorus := NewInsert(Chorus)
+	chorus.Mix = 1
+
+	// The wet impulse arrives after the modulated delay, at different times on both sides
+	response := streamChain(impulseStreamer(), testSampleRate.N(50*time.Millisecond), chorus)
+	peakAt := func(ch int) int {
+		peak := 0
+		for i := range response {
+			if math.Abs(response[i][ch]) > math.Abs(response[peak][ch]) {
+				peak = i
+			}
+		}
+		return peak
+	}
+
+	left, right := peakAt(0), peakAt(1)
+	lowest, highest := testSampleRate.N(10*time.Millisecond), testSampleRate.N(20*time.Millisecond)
+	if left < lowest || left > highest || right < lowest || right > highest {
+		t.Errorf("Expected the chorus taps between %d and %d samples, got %d and %d", lowest, highest, left, right)
+	}
+	if left == right {
+		t.Errorf("Expected the chorus to spread the sides, both taps at %d", left)
+	}
+
+	// Mixed with the dry signal the sine is changed
+	length := testSampleRate.N(100 * time.Millisecond)
+	clean := streamChain(quietSine(), length)
+	chorus.Mix = 0.5
+	changed := 0.0
+	for i, sample := range streamChain(quietSine(), length, chorus) {
+		changed = max(changed, math.Abs(sample[0]-clean[i][0]))
+	}
+	if changed < 0.01 {
+		t.Errorf("Expected the chorus to change the signal, largest difference is %f", changed)
+	}
+}
+
+func TestInsertChainSettings(t *testing.T) {
+	// Changing settings of the same effect types keeps the effects and their state
+	chain := newInsertChain(quietSine(), testSampleRate, []Insert{NewInsert(Overdrive), NewInsert(Flanger)})
+	effects := chain.effects
+
+	flanger := NewInsert(Flanger)
+	flanger.Depth = 0.2
+	chain.setInserts([]Insert{NewInsert(Overdrive), flanger})
+	if chain.effects[1] != effects[1] || chain.effects[1].(*modulatedDelayEffect).insert.Depth != 0.2 {
+		t.Error("Expected the flanger to be updated in place")
+	}
+
+	chain.setInserts([]Insert{NewInsert(Chorus)})
+	if len(chain.effects) != 1 || chain.effects[0].(*modulatedDelayEffect).insert.Type != Chorus {
+		t.Errorf("Expected the chain to be rebuilt with a chorus, got %d effects", len(chain.effects))
+	}
+}
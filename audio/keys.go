@@ -0,0 +1,85 @@
+package audio
+
+import "slices"
+
+// KeyMode represents the scale of a musical key
+type KeyMode string
+
+const (
+	MajorMode           KeyMode = "major"
+	MinorMode           KeyMode = "minor"
+	HarmonicMinorMode   KeyMode = "harmonic minor"
+	DorianMode          KeyMode = "dorian"
+	MixolydianMode      KeyMode = "mixolydian"
+	MajorPentatonicMode KeyMode = "major pentatonic"
+	MinorPentatonicMode KeyMode = "minor pentatonic"
+)
+
+// KeyModes lists the available key modes
+var KeyModes = []KeyMode{MajorMode, MinorMode, HarmonicMinorMode, DorianMode, MixolydianMode, MajorPentatonicMode, MinorPentatonicMode}
+
+// keyIntervals holds the semitones of the scale degrees above the root for each mode
+var keyIntervals = map[KeyMode][]int{
+	MajorMode:           {0, 2, 4, 5, 7, 9, 11},
+	MinorMode:           {0, 2, 3, 5, 7, 8, 10},
+	HarmonicMinorMode:   {0, 2, 3, 5, 7, 8, 11},
+	DorianMode:          {0, 2, 3, 5, 7, 9, 10},
+	MixolydianMode:      {0, 2, 4, 5, 7, 9, 10},
+	MajorPentatonicMode: {0, 2, 4, 7, 9},
+	MinorPentatonicMode: {0, 3, 5, 7, 10},
+}
+
+// Key is the root and mode used for scale aware transposing.
+// The zero value is C major.
+type Key struct {
+	Root Base    // empty is C
+	Mode KeyMode // empty is major
+}
+
+func (k Key) String() string {
+	root := k.Root
+	if root == "" {
+		root = BaseC
+	}
+	mode := k.Mode
+	if mode == "" {
+		mode = MajorMode
+	}
+	return string(root) + " " + string(mode)
+}
+
+// TransposeInKey moves the note by degrees of the scale of the key. Notes outside the
+// scale keep their distance to the scale note below them. Reports false for the off note
+// or when the result leaves the playable range.
+func (note Note) TransposeInKey(key Key, degrees int) (Note, bool) {
+	if IsOff(note) {
+		return note, false
+	}
+
+	intervals, ok := keyIntervals[key.Mode]
+	if !ok {
+		intervals = keyIntervals[MajorMode]
+	}
+	root := max(slices.Index(bases, key.Root), 0)
+
+	// Find the scale degree at or below the note, counted from the root of octave -1
+	relative := int(note) - root
+	octave := floorDiv(relative, SemitonesPerOctave)
+	semitone := relative - octave*SemitonesPerOctave
+	degree := 0
+	for i, interval := range intervals {
+		if interval <= semitone {
+			degree = i
+		}
+	}
+	offset := semitone - intervals[degree]
+
+	degree += octave*len(intervals) + degrees
+	octave = floorDiv(degree, len(intervals))
+	degree -= octave * len(intervals)
+	transposed, ok := MIDINote(root + octave*SemitonesPerOctave + intervals[degree] + offset)
+	if !ok {
+		return note, false
+	}
+	return transposed, true
+}
@@ -0,0 +1,132 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	limiterLookAhead = 5 * time.Millisecond
+	limiterRelease   = 100 * time.Millisecond
+
+	// limitingThreshold is the gain below which the limiter reports that it is limiting (~0.5dB)
+	limitingThreshold = 0.944
+)
+
+// Limiter holds the settings of the master output stage. The zero value is a
+// look-ahead limiter with a ceiling at full scale and no headroom scaling.
+type Limiter struct {
+	Headroom float64 // gain in dB applied before the limiter, e.g. -6 to leave room for chords
+	Ceiling  float64 // maximum output level in dB, e.g. -0.3
+	SoftClip bool    // use a tanh soft clipper instead of the look-ahead limiter
+	Bypass   bool    // pass the signal through untouched
+}
+
+// limiterEffect implements beep.Streamer for headroom scaling followed by a look-ahead limiter or soft clipper
+type limiterEffect struct {
+	Streamer    beep.Streamer
+	sampleRate  beep.SampleRate
+	limiter     Limiter
+	buffer      [][2]float64 // look-ahead delay line
+	pos         int
+	target      float64 // lowest gain required by the samples in the look-ahead window
+	hold        int     // samples until target may rise again
+	gain        float64
+	attackCoef  float64
+	releaseCoef float64
+	minGain     float64 // lowest gain since the last status query
+}
+
+func newLimiterEffect(streamer beep.Streamer, sampleRate beep.SampleRate, limiter Limiter) *limiterEffect {
+	lookAhead := sampleRate.N(limiterLookAhead)
+
+	return &limiterEffect{
+		Streamer:   streamer,
+		sampleRate: sampleRate,
+		limiter:    limiter,
+		buffer:     make([][2]float64, lookAhead),
+		target:     1,
+		gain:       1,
+		// Reach the target gain within the look-ahead window
+		attackCoef:  1 - math.Exp(-5/float64(lookAhead)),
+		releaseCoef: 1 - math.Exp(-1/float64(sampleRate.N(limiterRelease))),
+		minGain:     1,
+	}
+}
+
+// Stream fills the samples buffer with the limited signal
+func (l *limiterEffect) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = l.Streamer.Stream(samples)
+
+	if l.limiter.Bypass {
+		return n, ok
+	}
+
+	headroom := decibelsToGain(l.limiter.Headroom)
+	ceiling := decibelsToGain(min(l.limiter.Ceiling, 0))
+
+	for i := 0; i < n; i++ {
+		in := [2]float64{samples[i][0] * headroom, samples[i][1] * headroom}
+
+		if l.limiter.SoftClip {
+			samples[i][0] = ceiling * math.Tanh(in[0]/ceiling)
+			samples[i][1] = ceiling * math.Tanh(in[1]/ceiling)
+
+			if peak := max(math.Abs(in[0]), math.Abs(in[1])); peak > ceiling {
+				l.minGain = min(l.minGain, ceiling/peak)
+			}
+			continue
+		}
+
+		// The gain follows the incoming peak while the output is read from the delay line
+		target := 1.0
+		if peak := max(math.Abs(in[0]), math.Abs(in[1])); peak > ceiling {
+			target = ceiling / peak
+		}
+
+		// Hold the lowest target until the peak has left the delay line
+		if target <= l.target {
+			l.target = target
+			l.hold = len(l.buffer)
+		} else if l.hold > 0 {
+			l.hold--
+		} else {
+			l.target = target
+		}
+
+		if l.target < l.gain {
+			l.gain += (l.target - l.gain) * l.attackCoef
+		} else {
+			l.gain += (l.target - l.gain) * l.releaseCoef
+		}
+		l.minGain = min(l.minGain, l.gain)
+
+		out := l.buffer[l.pos]
+		l.buffer[l.pos] = in
+		l.pos = (l.pos + 1) % len(l.buffer)
+
+		// Hard clip at the ceiling catches peaks the smoothed gain could not reach in time
+		samples[i][0] = min(max(out[0]*l.gain, -ceiling), ceiling)
+		samples[i][1] = min(max(out[1]*l.gain, -ceiling), ceiling)
+	}
+
+	return n, ok
+}
+
+// limiting reports whether the limiter reduced the gain since the last call
+func (l *limiterEffect) limiting() bool {
+	limiting := l.minGain < limitingThreshold
+	l.minGain = 1
+	return limiting
+}
+
+// Err returns any error of the wrapped streamer
+func (l *limiterEffect) Err() error {
+	return l.Streamer.Err()
+}
+
+func decibelsToGain(decibels float64) float64 {
+	return math.Pow(10, decibels/20)
+}
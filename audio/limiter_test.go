@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// chordStreamer streams full scale sines of the frequencies summed, peaking at their count
+func chordStreamer(sampleRate beep.SampleRate, frequencies ...float64) beep.Streamer {
+	pos := 0
+	return beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			t := float64(pos) / float64(sampleRate)
+			sum := 0.0
+			for _, frequency := range frequencies {
+				sum += math.Sin(2 * math.Pi * frequency * t)
+			}
+			samples[i] = [2]float64{sum, -sum}
+			pos++
+		}
+		return len(samples), true
+	})
+}
+
+func TestLimiterCeiling(t *testing.T) {
+	// C major seventh chord, summing to almost four times full scale
+	chord := []float64{261.63, 329.63, 392.00, 493.88}
+
+	for _, limiter := range []Limiter{
+		{Ceiling: -0.3},
+		{Ceiling: -6, Headroom: 3},
+		{Ceiling: -0.3, SoftClip: true},
+		{Ceiling: -6, Headroom: 3, SoftClip: true},
+	} {
+		effect := newLimiterEffect(chordStreamer(testSampleRate, chord...), testSampleRate, limiter)
+		ceiling := decibelsToGain(limiter.Ceiling)
+
+		samples := make([][2]float64, testSampleRate.N(time.Second))
+		effect.Stream(samples)
+		for i, sample := range samples {
+			if math.Abs(sample[0]) > ceiling+1e-9 || math.Abs(sample[1]) > ceiling+1e-9 {
+				t.Fatalf("Expected %+v to stay below %f, got %v at %d", limiter, ceiling, sample, i)
+			}
+		}
+		if !effect.limiting() {
+			t.Errorf("Expected %+v to report limiting", limiter)
+		}
+	}
+}
+
+func TestLimiterBypass(t *testing.T) {
+	effect := newLimiterEffect(chordStreamer(testSampleRate, 440, 550), testSampleRate, Limiter{Ceiling: -6, Bypass: true})
+	samples := make([][2]float64, 1000)
+	effect.Stream(samples)
+
+	peak := 0.0
+	for _, sample := range samples {
+		peak = max(peak, math.Abs(sample[0]))
+	}
+	if peak < 1.5 {
+		t.Errorf("Expected the bypassed chord to pass untouched, peak is %f", peak)
+	}
+}
@@ -0,0 +1,240 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// MacroTickRate is the rate in Hz at which macro sequences advance, matching NTSC frame based trackers
+const MacroTickRate = 60
+
+// MacroType represents the parameter a macro sequence controls
+type MacroType string
+
+const (
+	MacroVolume   MacroType = "volume"   // level 0..15 per tick
+	MacroArpeggio MacroType = "arpeggio" // semitone offset from the played note per tick
+	MacroPitch    MacroType = "pitch"    // pitch offset in cents per tick
+	MacroDuty     MacroType = "duty"     // pulse width of square oscillators, 0 = 12.5%, 1 = 25%, 2 = 50%, 3 = 75%
+)
+
+// MacroTypes lists all macro types in editor order
+var MacroTypes = []MacroType{MacroVolume, MacroArpeggio, MacroPitch, MacroDuty}
+
+// MacroRange returns the lowest and highest value of a macro type
+func MacroRange(macroType MacroType) (lowest, highest int) {
+	switch macroType {
+	case MacroVolume:
+		return 0, 15
+	case MacroArpeggio:
+		return -24, 24
+	case MacroPitch:
+		return -100, 100
+	case MacroDuty:
+		return 0, 3
+	}
+	return 0, 0
+}
+
+var dutyCycles = []float64{0.125, 0.25, 0.5, 0.75}
+
+// Macro is a per-tick value sequence. After the last value the sequence jumps to the
+// loop point, or holds the last value. With a release point the sequence loops or holds
+// before it while the note is held, and continues after it once the note is released.
+type Macro struct {
+	Values  []int
+	Loop    int // index to jump back to after the last value, -1 for no loop
+	Release int // index at which the sequence waits for the note release, -1 for no release point
+}
+
+// NewMacro creates an empty macro without loop and release point
+func NewMacro() Macro {
+	return Macro{Loop: -1, Release: -1}
+}
+
+// Active reports whether the macro has values
+func (m Macro) Active() bool {
+	return len(m.Values) > 0
+}
+
+// Macros holds the macro sequences of an instrument
+type Macros struct {
+	Volume   Macro
+	Arpeggio Macro
+	Pitch    Macro
+	Duty     Macro
+}
+
+// Get returns the macro of the given type
+func (m *Macros) Get(macroType MacroType) *Macro {
+	switch macroType {
+	case MacroArpeggio:
+		return &m.Arpeggio
+	case MacroPitch:
+		return &m.Pitch
+	case MacroDuty:
+		return &m.Duty
+	}
+	return &m.Volume
+}
+
+// Active reports whether any macro has values
+func (m Macros) Active() bool {
+	return m.Volume.Active() || m.Arpeggio.Active() || m.Pitch.Active() || m.Duty.Active()
+}
+
+// macroState steps through a macro sequence
+type macroState struct {
+	macro Macro
+	pos   int
+}
+
+// value returns the current value, fallback when the macro is empty
+func (s *macroState) value(fallback int) int {
+	if !s.macro.Active() {
+		return fallback
+	}
+	return s.macro.Values[s.pos]
+}
+
+// advance moves to the value of the next tick
+func (s *macroState) advance(released bool) {
+	last := len(s.macro.Values) - 1
+	if last < 0 {
+		return
+	}
+
+	loop, release := s.macro.Loop, s.macro.Release
+	if loop > last {
+		loop = -1
+	}
+	if release > last {
+		release = -1
+	}
+
+	switch {
+	case !released && release >= 0 && s.pos >= release:
+		// Loop or hold before the release point until the note is released
+		if loop >= 0 && loop < release {
+			s.pos = loop
+		} else {
+			s.pos = release
+		}
+	case s.pos >= last:
+		// Loops before the release point only run while the note is held
+		if loop >= 0 && (release < 0 || loop > release) {
+			s.pos = loop
+		} else {
+			s.pos = last
+		}
+	default:
+		s.pos++
+	}
+}
+
+// release continues the sequence after the release point
+func (s *macroState) release() {
+	release := s.macro.Release
+	if release >= 0 && release < len(s.macro.Values)-1 && s.pos <= release {
+		s.pos = release + 1
+	}
+}
+
+// macroVoice implements beep.Streamer running the macros of a voice at the tick rate,
+// driving the frequency and duty of its oscillators and scaling its output volume
+type macroVoice struct {
+	Streamer    beep.Streamer
+	oscillators []*oscillatorGenerator
+	note        Note
+	tuning      Tuning
+	volume      macroState
+	arpeggio    macroState
+	pitch       macroState
+	duty        macroState
+	tickLength  int
+	tickLeft    int // samples left in the current tick
+	releaseAt   int // sample at which the note is released
+	pos         int
+	released    bool
+	gain        float64
+}
+
+func newMacroVoice(streamer beep.Streamer, sampleRate beep.SampleRate, macros Macros, note Note, tuning Tuning, releaseAt int, oscillators ...*oscillatorGenerator) *macroVoice {
+	return &macroVoice{
+		Streamer:    streamer,
+		oscillators: oscillators,
+		note:        note,
+		tuning:      tuning,
+		volume:      macroState{macro: macros.Volume},
+		arpeggio:    macroState{macro: macros.Arpeggio},
+		pitch:       macroState{macro: macros.Pitch},
+		duty:        macroState{macro: macros.Duty},
+		tickLength:  max(int(sampleRate)/MacroTickRate, 1),
+		releaseAt:   releaseAt,
+	}
+}
+
+// tick applies the macro values of the current tick and advances the sequences
+func (v *macroVoice) tick() {
+	if !v.released && v.pos >= v.releaseAt {
+		v.released = true
+		for _, state := range []*macroState{&v.volume, &v.arpeggio, &v.pitch, &v.duty} {
+			state.release()
+		}
+	}
+
+	lowest, highest := MacroRange(MacroVolume)
+	v.gain = float64(min(max(v.volume.value(highest), lowest), highest)) / float64(highest)
+
+	frequency := 0.0
+	if note, ok := v.note.Transpose(v.arpeggio.value(0)); ok {
+		frequency = v.tuning.Frequency(note) * math.Pow(2, float64(v.pitch.value(0))/1200)
+	}
+
+	duty := 0.0
+	if v.duty.macro.Active() {
+		duty = dutyCycles[min(max(v.duty.value(0), 0), len(dutyCycles)-1)]
+	}
+
+	for _, oscillator := range v.oscillators {
+		oscillator.frequency = frequency
+		oscillator.duty = duty
+	}
+
+	for _, state := range []*macroState{&v.volume, &v.arpeggio, &v.pitch, &v.duty} {
+		state.advance(v.released)
+	}
+	v.tickLeft = v.tickLength
+}
+
+// Stream fills the samples buffer with the voice, updating the macros at every tick
+func (v *macroVoice) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		if v.tickLeft == 0 {
+			v.tick()
+		}
+
+		chunk := min(len(samples)-n, v.tickLeft)
+		streamed, ok := v.Streamer.Stream(samples[n : n+chunk])
+		for i := n; i < n+streamed; i++ {
+			samples[i][0] *= v.gain
+			samples[i][1] *= v.gain
+		}
+
+		n += streamed
+		v.pos += streamed
+		v.tickLeft -= streamed
+
+		if !ok || streamed < chunk {
+			return n, n > 0
+		}
+	}
+
+	return n, true
+}
+
+// Err returns any error of the wrapped streamer
+func (v *macroVoice) Err() error {
+	return v.Streamer.Err()
+}
@@ -0,0 +1,77 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// tickSampleRate gives macro ticks of ten samples
+const tickSampleRate = beep.SampleRate(MacroTickRate * 10)
+
+// constantStreamer streams full scale samples forever
+var constantStreamer = beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		samples[i] = [2]float64{1, 1}
+	}
+	return len(samples), true
+})
+
+// streamVolumes streams the given number of ticks and returns the volume of every tick
+func streamVolumes(t *testing.T, voice *macroVoice, ticks int) []int {
+	t.Helper()
+	samples := make([][2]float64, ticks*voice.tickLength)
+	if n, ok := voice.Stream(samples); n != len(samples) || !ok {
+		t.Fatalf("Expected %d samples, got %d (%v)", len(samples), n, ok)
+	}
+
+	_, highest := MacroRange(MacroVolume)
+	volumes := make([]int, ticks)
+	for i := range volumes {
+		volumes[i] = int(math.Round(samples[i*voice.tickLength][0] * float64(highest)))
+	}
+	return volumes
+}
+
+func expectVolumes(t *testing.T, got, want []int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected volumes %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected volumes %v, got %v", want, got)
+		}
+	}
+}
+
+func TestMacroVoiceLoop(t *testing.T) {
+	macros := Macros{Volume: Macro{Values: []int{1, 2, 3}, Loop: 1, Release: -1}}
+	voice := newMacroVoice(constantStreamer, tickSampleRate, macros, NewNote(BaseA, Octave4), Tuning{}, math.MaxInt)
+
+	expectVolumes(t, streamVolumes(t, voice, 7), []int{1, 2, 3, 2, 3, 2, 3})
+}
+
+func TestMacroVoiceHoldsLastValue(t *testing.T) {
+	macros := Macros{Volume: Macro{Values: []int{15, 8, 4}, Loop: -1, Release: -1}}
+	voice := newMacroVoice(constantStreamer, tickSampleRate, macros, NewNote(BaseA, Octave4), Tuning{}, math.MaxInt)
+
+	expectVolumes(t, streamVolumes(t, voice, 5), []int{15, 8, 4, 4, 4})
+}
+
+func TestMacroVoiceRelease(t *testing.T) {
+	// Loops over 6, 9, 12 while held, then jumps past the release point and holds 15
+	macros := Macros{Volume: Macro{Values: []int{3, 6, 9, 12, 15}, Loop: 1, Release: 3}}
+	voice := newMacroVoice(constantStreamer, tickSampleRate, macros, NewNote(BaseA, Octave4), Tuning{}, 6*int(tickSampleRate)/MacroTickRate)
+
+	expectVolumes(t, streamVolumes(t, voice, 9), []int{3, 6, 9, 12, 6, 9, 15, 15, 15})
+}
+
+func TestMacroVoiceHoldsAtRelease(t *testing.T) {
+	// Without a loop the sequence waits on the release point, then runs to the end
+	macros := Macros{Volume: Macro{Values: []int{15, 10, 5, 2}, Loop: -1, Release: 1}}
+	voice := newMacroVoice(constantStreamer, tickSampleRate, macros, NewNote(BaseA, Octave4), Tuning{}, 4*int(tickSampleRate)/MacroTickRate)
+
+	expectVolumes(t, streamVolumes(t, voice, 7), []int{15, 10, 10, 10, 5, 2, 2})
+}
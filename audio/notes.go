@@ -2,36 +2,114 @@ package audio
 
 import (
 	"fmt"
-	"math"
+	"slices"
+	"strings"
 )
 
-type Note struct {
-	Base   Base
-	Octave Octave
-}
+// Note is a pitch held as MIDI note number, C-4 (middle C) is 60 and A-4 is 69.
+// The zero value is the off note, playable notes span C-0 (12) to G-9 (127).
+type Note int
+
+const (
+	noteOff Note = 0
+
+	// MinNote and MaxNote are the lowest and highest playable notes
+	MinNote Note = 12
+	MaxNote Note = 127
+
+	// SemitonesPerOctave is the number of semitones to transpose a note by one octave
+	SemitonesPerOctave = 12
 
+	// defaultReferenceNote (A-4) is tuned to the reference pitch of a tuning
+	defaultReferenceNote Note = 69
+)
+
+// NewNote creates a note from its base and octave, invalid or out of range notes are off
 func NewNote(base Base, octave Octave) Note {
-	return Note{Base: base, Octave: octave}
+	semitone := slices.Index(bases, base)
+	if semitone < 0 {
+		return noteOff
+	}
+
+	note, ok := MIDINote(int(octave+1)*SemitonesPerOctave + semitone)
+	if !ok {
+		return noteOff
+	}
+	return note
+}
+
+// MIDINote creates a note from a MIDI note number, reporting whether it is in the playable range
+func MIDINote(number int) (Note, bool) {
+	note := Note(number)
+	if note < MinNote || note > MaxNote {
+		return noteOff, false
+	}
+	return note, true
 }
 
 func Off() Note {
-	return Note{Base: BaseOff, Octave: Octave0}
+	return noteOff
 }
 
 func IsOff(note Note) bool {
-	return note.Base == BaseOff
+	return note == noteOff
+}
+
+// ParseNote parses a note as formatted by String, e.g. "C-4", "F#2" or "---" for the off note
+func ParseNote(text string) (Note, error) {
+	if text == noteOff.String() {
+		return noteOff, nil
+	}
+
+	if len(text) != 3 {
+		return noteOff, fmt.Errorf("invalid note %q", text)
+	}
+
+	base := Base(strings.TrimSuffix(text[:2], "-"))
+	octave := text[2] - '0'
+	if !slices.Contains(bases, base) || octave > byte(MaxOctave) {
+		return noteOff, fmt.Errorf("invalid note %q", text)
+	}
+
+	note := NewNote(base, Octave(octave))
+	if IsOff(note) {
+		return noteOff, fmt.Errorf("note %q out of range", text)
+	}
+	return note, nil
+}
+
+// MIDI returns the MIDI note number, 0 for the off note
+func (note Note) MIDI() int {
+	return int(note)
+}
+
+// Base returns the pitch class of the note
+func (note Note) Base() Base {
+	if IsOff(note) {
+		return BaseOff
+	}
+	return bases[int(note)%SemitonesPerOctave]
+}
+
+// Octave returns the octave of the note, C-4 starts octave 4
+func (note Note) Octave() Octave {
+	if IsOff(note) {
+		return Octave0
+	}
+	return Octave(int(note)/SemitonesPerOctave - 1)
 }
 
 func (note Note) String() string {
-	if note.Base == BaseOff {
+	if IsOff(note) {
 		return "---"
 	}
 
-	if len(string(note.Base)) < 2 {
-		return fmt.Sprintf("%s-%d", note.Base, note.Octave)
+	base := note.Base()
+	if len(string(base)) < 2 {
+		return fmt.Sprintf("%s-%d", base, note.Octave())
 	}
 
-	return fmt.Sprintf("%s%d", note.Base, note.Octave)
+	return fmt.Sprintf("%s%d", base, note.Octave())
 }
 
 type Base string
@@ -52,6 +130,9 @@ const (
 	BaseOff Base = "---"
 )
 
+// bases lists the pitch classes in semitone order starting at C
+var bases = []Base{BaseC, BaseCs, BaseD, BaseDs, BaseE, BaseF, BaseFs, BaseG, BaseGs, BaseA, BaseAs, BaseB}
+
 type Octave int
 
 const (
@@ -64,44 +145,30 @@ const (
 	Octave6 Octave = 6
 	Octave7 Octave = 7
 	Octave8 Octave = 8
-)
+	Octave9 Octave = 9
 
-// Base note data (octave-agnostic)
-var noteBaseFrequencies = map[Base]float64{
-	"C":  261.63, // C4
-	"C#": 277.18, // C#4 (Db4)
-	"D":  293.66, // D4
-	"D#": 311.13, // D#4 (Eb4)
-	"E":  329.63, // E4
-	"F":  349.23, // F4
-	"F#": 369.99, // F#4 (Gb4)
-	"G":  392.00, // G4
-	"G#": 415.30, // G#4 (Ab4)
-	"A":  440.00, // A4
-	"A#": 466.16, // A#4 (Bb4)
-	"B":  493.88, // B4
-}
+	// MinOctave and MaxOctave bound the octaves of notes. Octave 9 is incomplete as it ends
+	// at G-9 (MaxNote), NewNote gives the off note for the bases above it.
+	MinOctave = Octave0
+	MaxOctave = Octave9
+)
 
+// Transpose moves the note by delta semitones, reporting false for the off note or when
+// the result leaves the playable range
 func (note Note) Transpose(delta int) (Note, bool) {
-	if note.Base == BaseOff {
+	if IsOff(note) {
 		return note, false
 	}
 
-	newOctave := note.Octave + Octave(delta)
-	if newOctave > Octave8 {
+	transposed, ok := MIDINote(int(note) + delta)
+	if !ok {
 		return note, false
 	}
 
-	return Note{Base: note.Base, Octave: newOctave}, true
+	return transposed, true
 }
 
-func (note Note) Frequency() float64 {
-	baseFreq, ok := noteBaseFrequencies[note.Base]
-	if !ok {
-		return 0
-	}
-
-	// Reference octave is 4 in noteBaseFrequencies
-	offset := float64(note.Octave - 4)
-	return baseFreq * math.Pow(2, offset)
+// Frequency returns the frequency of the note in Hz in the given tuning, 0 for the off note
+func (note Note) Frequency(tuning Tuning) float64 {
+	return tuning.Frequency(note)
 }
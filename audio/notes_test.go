@@ -0,0 +1,41 @@
+package audio
+
+import "testing"
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		note  Note
+		delta int
+		want  Note
+		ok    bool
+	}{
+		{NewNote(BaseB, Octave3), 1, NewNote(BaseC, Octave4), true},
+		{NewNote(BaseC, Octave4), -1, NewNote(BaseB, Octave3), true},
+		{NewNote(BaseA, Octave4), SemitonesPerOctave, NewNote(BaseA, Octave5), true},
+		{NewNote(BaseE, Octave8), 2 * SemitonesPerOctave, NewNote(BaseE, Octave8), false},
+		{NewNote(BaseFs, Octave9), 1, NewNote(BaseG, Octave9), true},
+		{NewNote(BaseG, Octave9), 1, NewNote(BaseG, Octave9), false},
+		{NewNote(BaseG, Octave8), SemitonesPerOctave, MaxNote, true},
+		{NewNote(BaseC, Octave0), -1, MinNote, false},
+		{NewNote(BaseC, Octave1), -SemitonesPerOctave, MinNote, true},
+		{Off(), 1, Off(), false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.note.Transpose(tt.delta)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Expected %s transposed by %d to be %s (%v), got %s (%v)", tt.note, tt.delta, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestNotesOfTheTopOctave(t *testing.T) {
+	if got := NewNote(BaseG, Octave9); got != MaxNote {
+		t.Errorf("Expected G-9 to be the highest note %d, got %d", MaxNote, got)
+	}
+	for _, base := range []Base{BaseGs, BaseA, BaseAs, BaseB} {
+		if got := NewNote(base, MaxOctave); !IsOff(got) {
+			t.Errorf("Expected %s%d above the highest note to be off, got %s", base, MaxOctave, got)
+		}
+	}
+}
@@ -29,6 +29,10 @@ const (
 // NewOscillator creates a beep.Streamer that generates the specified oscillator waveform
 // initialPhase is normalized [0..1) and independent of sample rate
 func NewOscillator(oscillatorType OscillatorType, frequency float64, sampleRate beep.SampleRate, initialPhase float64) beep.Streamer {
+	return newOscillatorGenerator(oscillatorType, frequency, sampleRate, initialPhase)
+}
+
+func newOscillatorGenerator(oscillatorType OscillatorType, frequency float64, sampleRate beep.SampleRate, initialPhase float64) *oscillatorGenerator {
 	return &oscillatorGenerator{
 		oscillatorType: oscillatorType,
 		frequency:      frequency,
@@ -43,6 +47,7 @@ type oscillatorGenerator struct {
 	frequency      float64
 	sampleRate     beep.SampleRate
 	phase          float64
+	duty           float64 // pulse width of the square wave, 0 = 50%
 }
 
 // Stream fills the samples buffer with oscillator waveform data
@@ -57,7 +62,11 @@ func (g *oscillatorGenerator) Stream(samples [][2]float64) (n int, ok bool) {
 			sample = math.Sin(2 * math.Pi * g.phase)
 
 		case Square:
-			if g.phase < 0.5 {
+			duty := g.duty
+			if duty <= 0 {
+				duty = 0.5
+			}
+			if g.phase < duty {
 				sample = 1.0
 			} else {
 				sample = -1.0
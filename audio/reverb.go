@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"github.com/gopxl/beep/v2"
+)
+
+// Freeverb tuning, delay lengths in samples at 44.1kHz
+var (
+	reverbCombLengths    = []int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpassLengths = []int{556, 441, 341, 225}
+)
+
+const (
+	reverbStereoSpread  = 23
+	reverbFixedGain     = 0.015
+	reverbRoomScale     = 0.28
+	reverbRoomOffset    = 0.7
+	reverbDampScale     = 0.4
+	reverbAllpassFactor = 0.5
+)
+
+// Reverb holds the settings of a Freeverb style algorithmic reverb
+type Reverb struct {
+	Size    float64 // room size [0..1]
+	Damping float64 // high frequency damping [0..1]
+	Mix     float64 // 0.0 = dry only, 1.0 = reverb only
+}
+
+type reverbComb struct {
+	buffer      []float64
+	idx         int
+	filterStore float64
+}
+
+func (c *reverbComb) process(input, feedback, damp float64) float64 {
+	output := c.buffer[c.idx]
+	c.filterStore = output*(1-damp) + c.filterStore*damp
+	c.buffer[c.idx] = input + c.filterStore*feedback
+	c.idx = (c.idx + 1) % len(c.buffer)
+	return output
+}
+
+type reverbAllpass struct {
+	buffer []float64
+	idx    int
+}
+
+func (a *reverbAllpass) process(input float64) float64 {
+	buffered := a.buffer[a.idx]
+	a.buffer[a.idx] = input + buffered*reverbAllpassFactor
+	a.idx = (a.idx + 1) % len(a.buffer)
+	return buffered - input
+}
+
+// reverbEffect implements beep.Streamer for a Freeverb style reverb
+type reverbEffect struct {
+	Streamer  beep.Streamer
+	reverb    Reverb
+	wetOnly   bool // used on send buses where the dry signal reaches the master directly
+	combs     [2][]reverbComb
+	allpasses [2][]reverbAllpass
+}
+
+func newReverbEffect(streamer beep.Streamer, sampleRate beep.SampleRate, reverb Reverb, wetOnly bool) *reverbEffect {
+	r := &reverbEffect{
+		Streamer: streamer,
+		reverb:   reverb,
+		wetOnly:  wetOnly,
+	}
+
+	scale := float64(sampleRate) / 44100
+	for ch := range 2 {
+		spread := ch * reverbStereoSpread
+		for _, length := range reverbCombLengths {
+			r.combs[ch] = append(r.combs[ch], reverbComb{buffer: make([]float64, int(float64(length+spread)*scale))})
+		}
+		for _, length := range reverbAllpassLengths {
+			r.allpasses[ch] = append(r.allpasses[ch], reverbAllpass{buffer: make([]float64, int(float64(length+spread)*scale))})
+		}
+	}
+
+	return r
+}
+
+// Stream fills the samples buffer with the reverberated signal
+func (r *reverbEffect) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = r.Streamer.Stream(samples)
+
+	feedback := min(max(r.reverb.Size, 0), 1)*reverbRoomScale + reverbRoomOffset
+	damp := min(max(r.reverb.Damping, 0), 1) * reverbDampScale
+	mix := min(max(r.reverb.Mix, 0), 1)
+
+	dry := 1 - mix
+	if r.wetOnly {
+		dry = 0
+	}
+
+	for i := 0; i < n; i++ {
+		input := (samples[i][0] + samples[i][1]) * reverbFixedGain
+
+		for ch := range 2 {
+			wet := 0.0
+			for c := range r.combs[ch] {
+				wet += r.combs[ch][c].process(input, feedback, damp)
+			}
+			for a := range r.allpasses[ch] {
+				wet = r.allpasses[ch][a].process(wet)
+			}
+
+			samples[i][ch] = samples[i][ch]*dry + wet*mix
+		}
+	}
+
+	return n, ok
+}
+
+// Err returns any error of the wrapped streamer
+func (r *reverbEffect) Err() error {
+	return r.Streamer.Err()
+}
@@ -0,0 +1,40 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// tailEnergy returns the energy of the reverb impulse response after the early reflections
+func tailEnergy(reverb Reverb) float64 {
+	response := impulseResponse(testSampleRate.N(2*time.Second), func(s beep.Streamer) beep.Streamer {
+		return newReverbEffect(s, testSampleRate, reverb, true)
+	})
+
+	energy := 0.0
+	for _, sample := range response[testSampleRate.N(200*time.Millisecond):] {
+		energy += sample[0]*sample[0] + sample[1]*sample[1]
+	}
+	return energy
+}
+
+func TestReverbTailGrowsWithSize(t *testing.T) {
+	previous := 0.0
+	for _, size := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		energy := tailEnergy(Reverb{Size: size, Damping: 0.5, Mix: 1})
+		if energy <= previous {
+			t.Errorf("Expected the tail of size %.2f to be longer, energy %g after %g", size, energy, previous)
+		}
+		previous = energy
+	}
+}
+
+func TestReverbDryMix(t *testing.T) {
+	// Without wet signal a reverb mixing in the dry signal passes the impulse only
+	response := impulseResponse(1000, func(s beep.Streamer) beep.Streamer {
+		return newReverbEffect(s, testSampleRate, Reverb{Size: 0.8, Mix: 0}, false)
+	})
+	expectTaps(t, response, map[int][2]float64{0: {1, 1}})
+}
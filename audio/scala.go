@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseScale reads a scale in the Scala .scl format
+func ParseScale(r io.Reader) (*Scale, error) {
+	lines, err := scalaLines(r, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) < 2 {
+		return nil, errors.New("scale is missing the description or note count")
+	}
+
+	count, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid note count %q", lines[1])
+	}
+
+	if len(lines)-2 < count {
+		return nil, fmt.Errorf("scale has %d of %d notes", len(lines)-2, count)
+	}
+
+	scale := &Scale{Description: strings.TrimSpace(lines[0])}
+	for _, line := range lines[2 : 2+count] {
+		cents, err := parseScalaPitch(firstField(line))
+		if err != nil {
+			return nil, err
+		}
+		scale.Cents = append(scale.Cents, cents)
+	}
+
+	return scale, nil
+}
+
+// ParseKeyboardMapping reads a keyboard mapping in the Scala .kbm format,
+// returning the mapping and the frequency of its reference note
+func ParseKeyboardMapping(r io.Reader) (KeyboardMapping, float64, error) {
+	lines, err := scalaLines(r, false)
+	if err != nil {
+		return KeyboardMapping{}, 0, err
+	}
+
+	if len(lines) < 7 {
+		return KeyboardMapping{}, 0, errors.New("keyboard mapping is missing header lines")
+	}
+
+	var header [7]float64
+	for i := range header {
+		value, err := strconv.ParseFloat(firstField(lines[i]), 64)
+		if err != nil {
+			return KeyboardMapping{}, 0, fmt.Errorf("invalid keyboard mapping value %q", lines[i])
+		}
+		header[i] = value
+	}
+
+	// Header: map size, first and last note, middle note, reference note and frequency, formal octave degree.
+	// The retuned note range is not used, every note in the playable range is mapped.
+	size := int(header[0])
+	mapping := KeyboardMapping{
+		Middle:        int(header[3]),
+		ReferenceNote: int(header[4]),
+		Explicit:      true,
+		OctaveDegree:  int(header[6]),
+	}
+	frequency := header[5]
+
+	if size < 0 || len(lines)-7 < size {
+		return KeyboardMapping{}, 0, fmt.Errorf("keyboard mapping has %d of %d keys", max(len(lines)-7, 0), size)
+	}
+
+	for _, line := range lines[7 : 7+size] {
+		field := firstField(line)
+		if field == "x" || field == "X" {
+			mapping.Degrees = append(mapping.Degrees, -1)
+			continue
+		}
+
+		degree, err := strconv.Atoi(field)
+		if err != nil {
+			return KeyboardMapping{}, 0, fmt.Errorf("invalid key mapping %q", line)
+		}
+		mapping.Degrees = append(mapping.Degrees, degree)
+	}
+
+	// An unmapped reference note has no pitch to tune the other notes against
+	if _, _, ok := mapping.degree(mapping.ReferenceNote); !ok {
+		return KeyboardMapping{}, 0, fmt.Errorf("reference note %d is not mapped to a degree", mapping.ReferenceNote)
+	}
+
+	return mapping, frequency, nil
+}
+
+// scalaLines returns the lines without comments, blank lines are kept when they may be a description
+func scalaLines(r io.Reader, keepBlank bool) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		// Only the description line of a scale may be empty
+		if strings.TrimSpace(line) == "" && !(keepBlank && len(lines) == 0) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseScalaPitch parses a pitch given in cents when it contains a period, otherwise as ratio
+func parseScalaPitch(field string) (float64, error) {
+	if strings.Contains(field, ".") {
+		cents, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pitch %q", field)
+		}
+		return cents, nil
+	}
+
+	numerator, denominator, found := strings.Cut(field, "/")
+	if !found {
+		denominator = "1"
+	}
+
+	n, err1 := strconv.ParseFloat(numerator, 64)
+	d, err2 := strconv.ParseFloat(denominator, 64)
+	if err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+		return 0, fmt.Errorf("invalid pitch %q", field)
+	}
+
+	return 1200 * math.Log2(n/d), nil
+}
+
+// firstField returns the first whitespace separated field, Scala ignores text after it
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,295 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// SfxCategory names the kind of sound effect the generator creates
+type SfxCategory string
+
+const (
+	SfxJump      SfxCategory = "jump"
+	SfxCoin      SfxCategory = "coin"
+	SfxExplosion SfxCategory = "explosion"
+	SfxLaser     SfxCategory = "laser"
+	SfxPowerup   SfxCategory = "powerup"
+	SfxRandom    SfxCategory = "random"
+)
+
+// SfxCategories lists all generator categories in selection order
+var SfxCategories = []SfxCategory{SfxJump, SfxCoin, SfxExplosion, SfxLaser, SfxPowerup, SfxRandom}
+
+const (
+	MinSfxFrequency = 20
+	MaxSfxFrequency = 4000
+	MaxSfxSlide     = 96   // semitones per second
+	MaxSfxJump      = 24   // semitones
+	MaxSfxTime      = 2000 // ms of a single envelope stage or the jump delay
+	MaxSfxVibrato   = 12   // semitones
+	MaxSfxSpeed     = 40   // Hz
+
+	sfxBlock = 64 // samples between pitch updates
+)
+
+// SfxWaves are the oscillator waveforms a sound effect plays
+var SfxWaves = []OscillatorType{Square, Sawtooth, Sine, Triangle, Noise}
+
+// Sfx holds the settings of a generated sound effect, one oscillator with a pitch sweep,
+// a pitch jump, vibrato and an attack, sustain and decay envelope.
+// The frequency is played at C-4, other notes transpose the effect relative to it.
+type Sfx struct {
+	Category  SfxCategory
+	Wave      OscillatorType
+	Duty      float64 // pulse width of the square wave, 0 = 50%
+	Frequency float64 // start frequency in Hz
+	Slide     float64 // pitch sweep in semitones per second, negative sweeps down
+	Jump      float64 // semitones the pitch jumps by after JumpTime, 0 for no jump
+	JumpTime  float64 // ms until the pitch jump
+	Vibrato   float64 // vibrato depth in semitones
+	Speed     float64 // vibrato rate in Hz
+	Attack    float64 // ms
+	Sustain   float64 // ms
+	Decay     float64 // ms
+	Punch     float64 // level boost at the start of the sustain, 0.0..1.0
+}
+
+// NewSfx generates a random sound effect of a category
+func NewSfx(category SfxCategory) Sfx {
+	sfx := Sfx{Category: category, Wave: Square}
+
+	switch category {
+	case SfxJump:
+		sfx.Duty = pick(0.25, 0.5)
+		sfx.Frequency = between(250, 600)
+		sfx.Slide = between(20, 60)
+		sfx.Attack = 0
+		sfx.Sustain = between(50, 150)
+		sfx.Decay = between(80, 250)
+	case SfxCoin:
+		sfx.Wave = pick(Square, Sine, Triangle)
+		sfx.Frequency = between(700, 1500)
+		sfx.Jump = pick(5.0, 7, 12)
+		sfx.JumpTime = between(40, 90)
+		sfx.Sustain = between(40, 120)
+		sfx.Decay = between(150, 400)
+		sfx.Punch = between(0.3, 0.6)
+	case SfxExplosion:
+		sfx.Wave = Noise
+		sfx.Frequency = between(40, 200)
+		sfx.Slide = -between(0, 20)
+		sfx.Sustain = between(50, 200)
+		sfx.Decay = between(400, 1200)
+		sfx.Punch = between(0.2, 0.8)
+	case SfxLaser:
+		sfx.Wave = pick(Square, Sawtooth, Sine)
+		sfx.Duty = pick(0.125, 0.25, 0.5)
+		sfx.Frequency = between(800, 2500)
+		sfx.Slide = -between(40, 96)
+		sfx.Sustain = between(40, 150)
+		sfx.Decay = between(50, 200)
+	case SfxPowerup:
+		sfx.Wave = pick(Square, Sawtooth)
+		sfx.Frequency = between(200, 500)
+		sfx.Slide = between(10, 40)
+		sfx.Vibrato = between(0, 1)
+		sfx.Speed = between(8, 20)
+		sfx.Sustain = between(150, 400)
+		sfx.Decay = between(100, 400)
+	default:
+		sfx = RandomSfx()
+	}
+
+	return sfx
+}
+
+// RandomSfx generates a sound effect with all settings chosen at random
+func RandomSfx() Sfx {
+	sfx := Sfx{
+		Category:  SfxRandom,
+		Wave:      pick(SfxWaves...),
+		Duty:      pick(0, 0.125, 0.25),
+		Frequency: math.Exp(between(math.Log(60), math.Log(2500))),
+		Slide:     between(-MaxSfxSlide, MaxSfxSlide) * rand.Float64(),
+		Attack:    between(0, 100) * rand.Float64(),
+		Sustain:   between(20, 500),
+		Decay:     between(50, 800),
+		Punch:     between(0, 1) * rand.Float64(),
+	}
+
+	if rand.IntN(3) == 0 {
+		sfx.Jump = between(-12, 12)
+		sfx.JumpTime = between(20, 200)
+	}
+	if rand.IntN(3) == 0 {
+		sfx.Vibrato = between(0, 2)
+		sfx.Speed = between(2, 30)
+	}
+
+	return sfx.clamped()
+}
+
+// Mutate returns the sound effect with each setting slightly changed at random
+func (s Sfx) Mutate() Sfx {
+	vary := func(value, spread float64) float64 {
+		if rand.IntN(2) == 0 {
+			return value
+		}
+		return value + between(-spread, spread)
+	}
+
+	s.Frequency *= math.Pow(2, vary(0, 2)/SemitonesPerOctave)
+	s.Slide = vary(s.Slide, 8)
+	s.Jump = math.Round(vary(s.Jump, 1))
+	s.JumpTime = vary(s.JumpTime, 20)
+	s.Vibrato = vary(s.Vibrato, 0.3)
+	s.Speed = vary(s.Speed, 2)
+	s.Attack = vary(s.Attack, 10)
+	s.Sustain = vary(s.Sustain, 30)
+	s.Decay = vary(s.Decay, 50)
+	s.Punch = vary(s.Punch, 0.1)
+
+	return s.clamped()
+}
+
+// clamped limits all settings to their ranges
+func (s Sfx) clamped() Sfx {
+	s.Frequency = min(max(s.Frequency, MinSfxFrequency), MaxSfxFrequency)
+	s.Slide = min(max(s.Slide, -MaxSfxSlide), MaxSfxSlide)
+	s.Jump = min(max(s.Jump, -MaxSfxJump), MaxSfxJump)
+	s.JumpTime = min(max(s.JumpTime, 0), MaxSfxTime)
+	s.Vibrato = min(max(s.Vibrato, 0), MaxSfxVibrato)
+	s.Speed = min(max(s.Speed, 0), MaxSfxSpeed)
+	s.Attack = min(max(s.Attack, 0), MaxSfxTime)
+	s.Sustain = min(max(s.Sustain, 0), MaxSfxTime)
+	s.Decay = min(max(s.Decay, 0), MaxSfxTime)
+	s.Punch = min(max(s.Punch, 0), 1)
+	return s
+}
+
+// Duration returns the length of the sound effect
+func (s Sfx) Duration() time.Duration {
+	return time.Duration((s.Attack + s.Sustain + s.Decay) * float64(time.Millisecond))
+}
+
+// between returns a random value in [low, high)
+func between(low, high float64) float64 {
+	return low + rand.Float64()*(high-low)
+}
+
+// pick returns one of the values at random
+func pick[T any](values ...T) T {
+	return values[rand.IntN(len(values))]
+}
+
+// SfxSynth implements Instrument playing one shot sound effects
+type SfxSynth struct {
+	sampleRate beep.SampleRate
+	sfx        Sfx
+	tuning     Tuning
+}
+
+// NewSfxSynth creates a sound effect instrument
+func NewSfxSynth(sampleRate beep.SampleRate, sfx Sfx, tuning Tuning) *SfxSynth {
+	return &SfxSynth{
+		sampleRate: sampleRate,
+		sfx:        sfx,
+		tuning:     tuning,
+	}
+}
+
+// Voice implements Instrument. Sound effects play their full length independent of the
+// note duration, legato is ignored as every note restarts the effect.
+func (s *SfxSynth) Voice(note Note, d time.Duration, legato bool) beep.Streamer {
+	ratio := 1.0
+	if middleC := s.tuning.Frequency(NewNote(BaseC, Octave4)); middleC > 0 && !IsOff(note) {
+		ratio = s.tuning.Frequency(note) / middleC
+	}
+
+	return newSfxVoice(s.sampleRate, s.sfx, ratio)
+}
+
+// OneShot returns the sound effect played once at its own frequency, as used for export
+func (s *SfxSynth) OneShot() beep.Streamer {
+	return newSfxVoice(s.sampleRate, s.sfx, 1)
+}
+
+// sfxVoice implements beep.Streamer sweeping the oscillator of a sound effect through the envelope
+type sfxVoice struct {
+	Streamer   beep.Streamer
+	oscillator *oscillatorGenerator
+	sfx        Sfx
+	frequency  float64 // start frequency including the transposition of the note
+	sampleRate float64
+	pos        int
+}
+
+func newSfxVoice(sampleRate beep.SampleRate, sfx Sfx, ratio float64) beep.Streamer {
+	frequency := sfx.Frequency * ratio
+	oscillator := newOscillatorGenerator(sfx.Wave, frequency, sampleRate, 0)
+	oscillator.duty = sfx.Duty
+
+	samples := max(sampleRate.N(sfx.Duration()), 1)
+	total := float64(samples)
+
+	// Punch starts the sustain at full level and settles on a lower one
+	envelope := Envelope{
+		Attack:  float64(sampleRate.N(time.Duration(sfx.Attack*float64(time.Millisecond)))) / total,
+		Sustain: 1 - sfx.Punch/2,
+		Release: float64(sampleRate.N(time.Duration(sfx.Decay*float64(time.Millisecond)))) / total,
+	}
+	if sfx.Punch > 0 {
+		envelope.Decay = (1 - envelope.Attack - envelope.Release) / 3
+	}
+
+	voice := &sfxVoice{
+		Streamer:   NewEnvelope(oscillator, samples, envelope),
+		oscillator: oscillator,
+		sfx:        sfx,
+		frequency:  frequency,
+		sampleRate: float64(sampleRate),
+	}
+
+	return beep.Take(samples, voice)
+}
+
+// update sets the oscillator frequency for the current position
+func (v *sfxVoice) update() {
+	t := float64(v.pos) / v.sampleRate // seconds
+
+	semitones := v.sfx.Slide * t
+	if v.sfx.Jump != 0 && t*1000 >= v.sfx.JumpTime {
+		semitones += v.sfx.Jump
+	}
+	if v.sfx.Vibrato > 0 {
+		semitones += v.sfx.Vibrato * math.Sin(2*math.Pi*v.sfx.Speed*t)
+	}
+
+	v.oscillator.frequency = min(max(v.frequency*math.Pow(2, semitones/SemitonesPerOctave), MinSfxFrequency), v.sampleRate/2)
+}
+
+// Stream fills the samples buffer with the sound effect, updating the pitch every block
+func (v *sfxVoice) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		v.update()
+
+		chunk := min(len(samples)-n, sfxBlock)
+		streamed, ok := v.Streamer.Stream(samples[n : n+chunk])
+		n += streamed
+		v.pos += streamed
+
+		if !ok || streamed < chunk {
+			return n, n > 0
+		}
+	}
+
+	return n, true
+}
+
+// Err returns any error of the wrapped streamer
+func (v *sfxVoice) Err() error {
+	return v.Streamer.Err()
+}
@@ -0,0 +1,31 @@
+package audio
+
+import "testing"
+
+func TestSfxVoices(t *testing.T) {
+	// Settings are random, so generate every category a few times
+	for _, category := range SfxCategories {
+		for range 20 {
+			sfx := NewSfx(category)
+			length := max(testSampleRate.N(sfx.Duration()), 1)
+			samples := streamAll(t, newSfxVoice(testSampleRate, sfx, 1), length)
+			if len(samples) != length {
+				t.Errorf("Expected %s %+v to last %d samples, got %d", category, sfx, length, len(samples))
+			}
+			expectAudible(t, samples)
+		}
+	}
+}
+
+func TestSfxMutations(t *testing.T) {
+	sfx := NewSfx(SfxCoin)
+	for range 50 {
+		sfx = sfx.Mutate()
+		length := max(testSampleRate.N(sfx.Duration()), 1)
+		samples := streamAll(t, newSfxVoice(testSampleRate, sfx, 2), length)
+		if len(samples) != length {
+			t.Errorf("Expected %+v to last %d samples, got %d", sfx, length, len(samples))
+		}
+		expectAudible(t, samples)
+	}
+}
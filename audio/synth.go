@@ -1,14 +1,29 @@
 package audio
 
 import (
+	"math"
 	"time"
 
 	"github.com/gopxl/beep/v2"
-	"github.com/gopxl/beep/v2/effects"
 )
 
+// Mixer holds the settings of the instrument mix stage combining both oscillators
 type Mixer struct {
-	Balance float64 // 0.0 = full left, 1.0 = full right
+	Balance    float64 // crossfade, 0.0 = oscillator 1 only, 1.0 = oscillator 2 only
+	EqualPower bool    // equal-power crossfade instead of a linear one
+	Level1     float64 // oscillator 1 level in dB, 0 = unity
+	Level2     float64 // oscillator 2 level in dB, 0 = unity
+	Pan1       float64 // oscillator 1 stereo position, -1.0 = left, 1.0 = right
+	Pan2       float64 // oscillator 2 stereo position, -1.0 = left, 1.0 = right
+}
+
+// Crossfade returns the gains of oscillator 1 and 2 for the balance
+func (m Mixer) Crossfade() (gain1, gain2 float64) {
+	balance := min(max(m.Balance, 0), 1)
+	if m.EqualPower {
+		return math.Cos(balance * math.Pi / 2), math.Sin(balance * math.Pi / 2)
+	}
+	return 1 - balance, balance
 }
 
 // Synth represents the audio synthesis engine
@@ -19,10 +34,12 @@ type Synth struct {
 	oscillator2 Oscillator
 	envelope2   Envelope
 	mixer       Mixer
+	macros      Macros
+	tuning      Tuning
 }
 
 // NewSynth creates a new synthesis engine
-func NewSynth(sampleRate beep.SampleRate, oscillator1 Oscillator, envelope1 Envelope, oscillator2 Oscillator, envelope2 Envelope, mixer Mixer) *Synth {
+func NewSynth(sampleRate beep.SampleRate, oscillator1 Oscillator, envelope1 Envelope, oscillator2 Oscillator, envelope2 Envelope, mixer Mixer, macros Macros, tuning Tuning) *Synth {
 	return &Synth{
 		sampleRate:  sampleRate,
 		oscillator1: oscillator1,
@@ -30,44 +47,89 @@ func NewSynth(sampleRate beep.SampleRate, oscillator1 Oscillator, envelope1 Enve
 		oscillator2: oscillator2,
 		envelope2:   envelope2,
 		mixer:       mixer,
+		macros:      macros,
+		tuning:      tuning,
 	}
 }
 
 func (s *Synth) Streamer(note Note, d time.Duration) beep.Streamer {
-	frequency := note.Frequency()
+	return s.Voice(note, d, false)
+}
+
+// Voice implements Instrument, legato voices start both envelopes at their sustain level
+func (s *Synth) Voice(note Note, d time.Duration, legato bool) beep.Streamer {
+	frequency := note.Frequency(s.tuning)
 
-	oscillator1 := NewOscillator(s.oscillator1.Type, frequency, s.sampleRate, s.oscillator1.Phase)
-	oscillator2 := NewOscillator(s.oscillator2.Type, frequency, s.sampleRate, s.oscillator2.Phase)
+	envelope1, envelope2 := s.envelope1, s.envelope2
+	if legato {
+		envelope1.Attack, envelope1.Decay = 0, 0
+		envelope2.Attack, envelope2.Decay = 0, 0
+	}
+
+	oscillator1 := newOscillatorGenerator(s.oscillator1.Type, frequency, s.sampleRate, s.oscillator1.Phase)
+	oscillator2 := newOscillatorGenerator(s.oscillator2.Type, frequency, s.sampleRate, s.oscillator2.Phase)
 
 	sampleDuration := s.sampleRate.N(d)
 
 	streamer1 := NewEnvelope(
 		oscillator1,
 		sampleDuration,
-		Envelope{
-			Attack:  s.envelope1.Attack,
-			Decay:   s.envelope1.Decay,
-			Sustain: s.envelope1.Sustain,
-			Release: s.envelope1.Release,
-		},
+		envelope1,
 	)
 
 	streamer2 := NewEnvelope(
 		oscillator2,
 		sampleDuration,
-		Envelope{
-			Attack:  s.envelope2.Attack,
-			Decay:   s.envelope2.Decay,
-			Sustain: s.envelope2.Sustain,
-			Release: s.envelope2.Release,
-		},
+		envelope2,
 	)
 
-	v := (s.mixer.Balance - 0.5) * 2 // Scale to -1 to 1
-	mix1 := &effects.Volume{Streamer: streamer1, Base: 2, Volume: -v, Silent: v >= 1}
-	mix2 := &effects.Volume{Streamer: streamer2, Base: 2, Volume: v, Silent: v <= -1}
+	gain1, gain2 := s.mixer.Crossfade()
+	mix1 := newStereoGain(streamer1, gain1*levelToGain(s.mixer.Level1), s.mixer.Pan1)
+	mix2 := newStereoGain(streamer2, gain2*levelToGain(s.mixer.Level2), s.mixer.Pan2)
+
+	var mixed beep.Streamer = beep.Mix(mix1, mix2)
 
-	mixed := beep.Mix(mix1, mix2)
+	if s.macros.Active() {
+		// Macros switch to their release part when envelope 1 starts releasing
+		releaseAt := sampleDuration - int(envelope1.Release*float64(sampleDuration))
+		mixed = newMacroVoice(mixed, s.sampleRate, s.macros, note, s.tuning, releaseAt, oscillator1, oscillator2)
+	}
 
 	return beep.Take(sampleDuration, mixed)
 }
+
+// stereoGain implements beep.Streamer applying a gain and stereo balance
+type stereoGain struct {
+	Streamer beep.Streamer
+	left     float64
+	right    float64
+}
+
+func newStereoGain(streamer beep.Streamer, gain float64, pan float64) *stereoGain {
+	left, right := balanceGains(pan)
+	return &stereoGain{Streamer: streamer, left: left * gain, right: right * gain}
+}
+
+// balanceGains returns the left and right gains of a balance position [-1..1], unity at
+// the center so the constant-power pan of the channel is the only pan law on a voice
+func balanceGains(pan float64) (left, right float64) {
+	pan = min(max(pan, -1), 1)
+	return min(1-pan, 1), min(1+pan, 1)
+}
+
+// Stream fills the samples buffer with the scaled and positioned signal
+func (g *stereoGain) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = g.Streamer.Stream(samples)
+
+	for i := 0; i < n; i++ {
+		samples[i][0] *= g.left
+		samples[i][1] *= g.right
+	}
+
+	return n, ok
+}
+
+// Err returns any error of the wrapped streamer
+func (g *stereoGain) Err() error {
+	return g.Streamer.Err()
+}
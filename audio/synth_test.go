@@ -0,0 +1,28 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOscillatorPan(t *testing.T) {
+	tests := []struct {
+		pan         float64
+		left, right float64
+	}{
+		{0, 1, 1},
+		{-1, 1, 0},
+		{1, 0, 1},
+		{0.5, 0.5, 1},
+		{-2, 1, 0},
+	}
+
+	for _, tt := range tests {
+		samples := [][2]float64{{1, 1}}
+		gain := newStereoGain(constantStreamer, 0.5, tt.pan)
+		gain.Stream(samples)
+		if math.Abs(samples[0][0]-0.5*tt.left) > 1e-9 || math.Abs(samples[0][1]-0.5*tt.right) > 1e-9 {
+			t.Errorf("Expected pan %.1f to give %.2f/%.2f, got %v", tt.pan, 0.5*tt.left, 0.5*tt.right, samples[0])
+		}
+	}
+}
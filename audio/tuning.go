@@ -0,0 +1,188 @@
+package audio
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	// DefaultReference is the frequency of A-4 when no reference pitch is set
+	DefaultReference = 440.0
+
+	// middleNote is where degree 0 of a scale is mapped unless a keyboard mapping says otherwise
+	middleNote = 60
+)
+
+// Temperament represents how the notes of an octave are tuned
+type Temperament string
+
+const (
+	EqualTemperament Temperament = "equal"
+	JustIntonation   Temperament = "just"
+	Meantone         Temperament = "meantone"
+	Pythagorean      Temperament = "pythagorean"
+	Werckmeister     Temperament = "werckmeister"
+	// ScalaTemperament uses the scale and keyboard mapping loaded from Scala files
+	ScalaTemperament Temperament = "scala"
+)
+
+// Temperaments lists the built-in temperaments
+var Temperaments = []Temperament{EqualTemperament, JustIntonation, Meantone, Pythagorean, Werckmeister}
+
+// temperamentCents holds the pitch of the 12 notes above the root in cents for the built-in temperaments
+var temperamentCents = map[Temperament][]float64{
+	EqualTemperament: {0, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100},
+	// 5-limit just intonation
+	JustIntonation: {0, 111.73, 203.91, 315.64, 386.31, 498.04, 590.22, 701.96, 813.69, 884.36, 1017.60, 1088.27},
+	// Quarter-comma meantone with the wolf fifth between G# and Eb
+	Meantone:    {0, 76.05, 193.16, 310.26, 386.31, 503.42, 579.47, 696.58, 772.63, 889.74, 1006.84, 1082.89},
+	Pythagorean: {0, 90.22, 203.91, 294.13, 407.82, 498.04, 611.73, 701.96, 792.18, 905.87, 996.09, 1109.78},
+	// Werckmeister III well temperament
+	Werckmeister: {0, 90.22, 192.18, 294.13, 390.22, 498.04, 588.27, 696.09, 792.18, 888.27, 996.09, 1092.18},
+}
+
+// Tuning holds the song-level tuning used to turn notes into frequencies.
+// The zero value is 12-tone equal temperament with A-4 at 440Hz.
+type Tuning struct {
+	Reference   float64     // frequency of the reference note in Hz, A-4 unless a keyboard mapping sets another, 0 = 440
+	Temperament Temperament // empty is equal temperament
+	Root        Base        // key the built-in temperaments are pure in, empty is C
+	Scale       *Scale      // Scala scale, used by ScalaTemperament
+}
+
+// Scale is a scale loaded from a Scala .scl file with an optional .kbm keyboard mapping
+type Scale struct {
+	Description string
+	Cents       []float64 // pitch of the degrees above degree 0 in cents, the last one is the period, e.g. 1200 for an octave
+	Mapping     KeyboardMapping
+}
+
+// KeyboardMapping maps MIDI notes to scale degrees as described by a Scala .kbm file.
+// The zero value maps every note linearly with degree 0 on C-4 and A-4 as reference.
+type KeyboardMapping struct {
+	Middle        int   // note on which degree 0 of the scale is mapped, 0 = C-4 unless Explicit
+	ReferenceNote int   // note tuned to the reference frequency, 0 = A-4 unless Explicit
+	Explicit      bool  // Middle and ReferenceNote are set, as by a .kbm file, so 0 is MIDI note 0
+	OctaveDegree  int   // degree of the formal octave, 0 = the scale period
+	Degrees       []int // degree of every key of the mapping pattern, -1 leaves the key silent, empty maps linearly
+}
+
+// middle returns the note on which degree 0 of the scale is mapped
+func (m KeyboardMapping) middle() int {
+	if m.Explicit || m.Middle > 0 {
+		return m.Middle
+	}
+	return middleNote
+}
+
+// referenceNote returns the note tuned to the reference frequency
+func (m KeyboardMapping) referenceNote() int {
+	if m.Explicit || m.ReferenceNote > 0 {
+		return m.ReferenceNote
+	}
+	return int(defaultReferenceNote)
+}
+
+// degree returns the degree a note is mapped to and the number of repetitions of the
+// mapping pattern from the middle note, false for keys left silent
+func (m KeyboardMapping) degree(note int) (degree, repeat int, ok bool) {
+	offset := note - m.middle()
+	size := len(m.Degrees)
+	if size == 0 {
+		return offset, 0, true
+	}
+
+	// Each repetition of the mapping pattern moves by one formal octave
+	repeat = floorDiv(offset, size)
+	degree = m.Degrees[offset-repeat*size]
+	return degree, repeat, degree >= 0
+}
+
+// ReferenceNote returns the MIDI note number tuned to the reference frequency, A-4 (69)
+// unless the keyboard mapping of a Scala scale sets another
+func (t Tuning) ReferenceNote() int {
+	if t.Temperament == ScalaTemperament && t.Scale != nil && len(t.Scale.Cents) > 0 {
+		return t.Scale.Mapping.referenceNote()
+	}
+	return int(defaultReferenceNote)
+}
+
+// Frequency returns the frequency of the note in Hz, 0 for the off note or unmapped keys
+func (t Tuning) Frequency(note Note) float64 {
+	if IsOff(note) {
+		return 0
+	}
+
+	reference := t.Reference
+	if reference <= 0 {
+		reference = DefaultReference
+	}
+
+	if t.Temperament == ScalaTemperament && t.Scale != nil && len(t.Scale.Cents) > 0 {
+		return t.Scale.frequency(note, reference)
+	}
+
+	table, ok := temperamentCents[t.Temperament]
+	if !ok {
+		table = temperamentCents[EqualTemperament]
+	}
+
+	root := max(slices.Index(bases, t.Root), 0)
+	scale := Scale{
+		Cents:   append(slices.Clone(table[1:]), 1200),
+		Mapping: KeyboardMapping{Middle: middleNote + root},
+	}
+	return scale.frequency(note, reference)
+}
+
+// frequency returns the frequency of the note relative to the reference note tuned to reference
+func (s *Scale) frequency(note Note, reference float64) float64 {
+	cents, ok := s.cents(int(note))
+	if !ok {
+		return 0
+	}
+	// The keyboard mapping loader rejects an unmapped reference note
+	referenceCents, ok := s.cents(s.Mapping.referenceNote())
+	if !ok {
+		return 0
+	}
+
+	return reference * math.Pow(2, (cents-referenceCents)/1200)
+}
+
+// cents returns the pitch of a MIDI note relative to degree 0 of the scale, false for unmapped keys
+func (s *Scale) cents(note int) (float64, bool) {
+	degree, repeat, ok := s.Mapping.degree(note)
+	if !ok {
+		return 0, false
+	}
+
+	period := s.degreeCents(len(s.Cents))
+	octave := period
+	if s.Mapping.OctaveDegree > 0 {
+		octave = s.degreeCents(s.Mapping.OctaveDegree)
+	}
+
+	// Degrees beyond the scale size wrap around by the period
+	wraps := floorDiv(degree, len(s.Cents))
+	degree -= wraps * len(s.Cents)
+
+	return float64(repeat)*octave + float64(wraps)*period + s.degreeCents(degree), true
+}
+
+// degreeCents returns the pitch of a degree within one period, degree 0 is 0 cents
+func (s *Scale) degreeCents(degree int) float64 {
+	if degree <= 0 {
+		return 0
+	}
+	return s.Cents[min(degree, len(s.Cents))-1]
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
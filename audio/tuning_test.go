@@ -0,0 +1,56 @@
+package audio
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const pentatonicScale = "! pentatonic.scl\n!\nJust pentatonic\n 5\n!\n9/8\n5/4\n3/2\n5/3\n2/1\n"
+
+func TestScalaTuning(t *testing.T) {
+	scale, err := ParseScale(strings.NewReader(pentatonicScale))
+	if err != nil {
+		t.Fatalf("ParseScale failed: %v", err)
+	}
+	if scale.Description != "Just pentatonic" || len(scale.Cents) != 5 {
+		t.Errorf("Unexpected scale %+v", scale)
+	}
+
+	mapping, frequency, err := ParseKeyboardMapping(strings.NewReader("! pentatonic.kbm\n5\n0\n127\n60\n60\n261.0\n5\n0\n1\n2\n3\n4\n"))
+	if err != nil {
+		t.Fatalf("ParseKeyboardMapping failed: %v", err)
+	}
+	if frequency != 261 || mapping.ReferenceNote != 60 || !mapping.Explicit {
+		t.Errorf("Unexpected mapping %+v, reference %f", mapping, frequency)
+	}
+
+	// Degree 2 (5/4) on the third key above the reference
+	scale.Mapping = mapping
+	tuning := Tuning{Reference: frequency, Temperament: ScalaTemperament, Scale: scale}
+	if got := tuning.Frequency(NewNote(BaseD, Octave4)); math.Abs(got-326.25) > 0.01 {
+		t.Errorf("Expected D-4=326.25Hz, got %f", got)
+	}
+}
+
+func TestKeyboardMappingOnNoteZero(t *testing.T) {
+	// MIDI note 0 as middle and reference note is not the default C-4 and A-4
+	mapping, frequency, err := ParseKeyboardMapping(strings.NewReader("0\n0\n127\n0\n0\n8.1757989\n0\n"))
+	if err != nil {
+		t.Fatalf("ParseKeyboardMapping failed: %v", err)
+	}
+
+	scale := &Scale{Cents: []float64{100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200}, Mapping: mapping}
+	tuning := Tuning{Reference: frequency, Temperament: ScalaTemperament, Scale: scale}
+	if got := tuning.Frequency(NewNote(BaseA, Octave4)); math.Abs(got-440) > 0.01 {
+		t.Errorf("Expected A-4=440Hz from note 0, got %f", got)
+	}
+}
+
+func TestKeyboardMappingUnmappedReference(t *testing.T) {
+	// The reference note 69 falls on the silent key 9 of the pattern
+	kbm := "12\n0\n127\n60\n69\n440.0\n12\n0\n1\n2\n3\n4\n5\n6\n7\n8\nx\n10\n11\n"
+	if _, _, err := ParseKeyboardMapping(strings.NewReader(kbm)); err == nil {
+		t.Error("Expected an error for an unmapped reference note")
+	}
+}
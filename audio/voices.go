@@ -0,0 +1,184 @@
+package audio
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	// DefaultPolyphony is the number of voices of a channel when no polyphony is set
+	DefaultPolyphony = 8
+	MaxPolyphony     = 16
+
+	// voiceFadeOut is the fade applied to stolen or stopped voices to avoid clicks
+	voiceFadeOut = 5 * time.Millisecond
+	// voiceLevelDecay is the falloff per buffer of the level used to find the quietest voice
+	voiceLevelDecay = 0.5
+)
+
+// StealMode represents which voice is taken over when all voices are in use
+type StealMode string
+
+const (
+	StealOldest   StealMode = "oldest"
+	StealQuietest StealMode = "quietest"
+)
+
+// VoiceMode represents how a new note treats the voices that are still sounding
+type VoiceMode string
+
+const (
+	// Retrigger starts every note with a new voice and a full envelope
+	Retrigger VoiceMode = "retrigger"
+	// Legato hands over from the sounding voice without restarting the attack, playing one note at a time
+	Legato VoiceMode = "legato"
+)
+
+// Voicing holds the voice allocation settings of a channel.
+// The zero value plays DefaultPolyphony voices, steals the oldest and retriggers.
+type Voicing struct {
+	Polyphony int
+	Steal     StealMode
+	Mode      VoiceMode
+}
+
+// MaxVoices returns the number of voices that may sound at the same time
+func (v Voicing) MaxVoices() int {
+	if v.Polyphony <= 0 {
+		return DefaultPolyphony
+	}
+	return min(v.Polyphony, MaxPolyphony)
+}
+
+// Instrument creates the voices played through a channel's voice allocator
+type Instrument interface {
+	// Voice returns a streamer playing the note for the duration, legato voices
+	// continue a sounding note and skip the attack
+	Voice(note Note, d time.Duration, legato bool) beep.Streamer
+}
+
+// voice is a note sounding on a channel
+type voice struct {
+	streamer beep.Streamer
+	order    int     // allocation order, lower is older
+	level    float64 // recent peak level
+	fade     int     // remaining fade out samples, -1 while the voice plays normally
+}
+
+// voiceAllocator implements beep.Streamer mixing the voices of a channel, limited to its polyphony
+type voiceAllocator struct {
+	voicing    Voicing
+	voices     []*voice
+	count      int
+	fadeLength int
+	buffer     [][2]float64
+}
+
+func newVoiceAllocator(sampleRate beep.SampleRate, voicing Voicing) *voiceAllocator {
+	return &voiceAllocator{
+		voicing:    voicing,
+		fadeLength: max(sampleRate.N(voiceFadeOut), 1),
+	}
+}
+
+// noteOn starts a voice for the note, stealing voices when the polyphony is exhausted
+func (a *voiceAllocator) noteOn(instrument Instrument, note Note, d time.Duration) {
+	playing := a.playing()
+
+	legato := a.voicing.Mode == Legato && len(playing) > 0
+	if legato {
+		for _, v := range playing {
+			a.fadeOut(v)
+		}
+	} else {
+		for len(playing) >= a.voicing.MaxVoices() {
+			victim := a.victim(playing)
+			a.fadeOut(victim)
+			playing = slices.DeleteFunc(playing, func(v *voice) bool { return v == victim })
+		}
+	}
+
+	a.voices = append(a.voices, &voice{
+		streamer: instrument.Voice(note, d, legato),
+		order:    a.count,
+		fade:     -1,
+	})
+	a.count++
+}
+
+// stop fades out all voices
+func (a *voiceAllocator) stop() {
+	for _, v := range a.playing() {
+		a.fadeOut(v)
+	}
+}
+
+// playing returns the voices that are not fading out
+func (a *voiceAllocator) playing() []*voice {
+	var playing []*voice
+	for _, v := range a.voices {
+		if v.fade < 0 {
+			playing = append(playing, v)
+		}
+	}
+	return playing
+}
+
+// victim picks the voice to steal according to the steal mode
+func (a *voiceAllocator) victim(playing []*voice) *voice {
+	if a.voicing.Steal == StealQuietest {
+		return slices.MinFunc(playing, func(x, y *voice) int {
+			if x.level != y.level {
+				return cmp.Compare(x.level, y.level)
+			}
+			return x.order - y.order
+		})
+	}
+
+	return slices.MinFunc(playing, func(x, y *voice) int { return x.order - y.order })
+}
+
+func (a *voiceAllocator) fadeOut(v *voice) {
+	v.fade = a.fadeLength
+}
+
+// Stream fills the samples buffer with the sum of all voices, it never drains
+func (a *voiceAllocator) Stream(samples [][2]float64) (n int, ok bool) {
+	clear(samples)
+	a.buffer = growBuffer(a.buffer, len(samples))
+
+	a.voices = slices.DeleteFunc(a.voices, func(v *voice) bool {
+		n, ok := v.streamer.Stream(a.buffer)
+
+		peak := 0.0
+		for i := 0; i < n; i++ {
+			gain := 1.0
+			if v.fade >= 0 {
+				if v.fade == 0 {
+					return true
+				}
+				gain = float64(v.fade) / float64(a.fadeLength)
+				v.fade--
+			}
+
+			samples[i][0] += a.buffer[i][0] * gain
+			samples[i][1] += a.buffer[i][1] * gain
+			peak = max(peak, math.Abs(a.buffer[i][0]), math.Abs(a.buffer[i][1]))
+		}
+		v.level = max(peak, v.level*voiceLevelDecay)
+
+		// Remove drained voices
+		return !ok || n < len(samples) || v.fade == 0
+	})
+
+	return len(samples), true
+}
+
+// Err returns any error that occurred during streaming
+func (a *voiceAllocator) Err() error {
+	return nil
+}
@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// testVoice streams a constant level and remembers how it was started
+type testVoice struct {
+	note   Note
+	level  float64
+	legato bool
+}
+
+func (v *testVoice) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		samples[i] = [2]float64{v.level, v.level}
+	}
+	return len(samples), true
+}
+
+func (v *testVoice) Err() error {
+	return nil
+}
+
+// testInstrument plays every note at the level set for it, full scale otherwise
+type testInstrument struct {
+	levels map[Note]float64
+}
+
+func (i testInstrument) Voice(note Note, d time.Duration, legato bool) beep.Streamer {
+	level, ok := i.levels[note]
+	if !ok {
+		level = 1
+	}
+	return &testVoice{note: note, level: level, legato: legato}
+}
+
+// playingNotes returns the notes of the voices that are not fading out in allocation order
+func playingNotes(a *voiceAllocator) []Note {
+	var notes []Note
+	for _, v := range a.playing() {
+		notes = append(notes, v.streamer.(*testVoice).note)
+	}
+	return notes
+}
+
+func streamVoices(a *voiceAllocator) {
+	a.Stream(make([][2]float64, 512))
+}
+
+func expectNotes(t *testing.T, a *voiceAllocator, want ...Note) {
+	t.Helper()
+	if got := playingNotes(a); !slices.Equal(got, want) {
+		t.Errorf("Expected playing notes %v, got %v", want, got)
+	}
+}
+
+func TestVoicePolyphonyStealsOldest(t *testing.T) {
+	allocator := newVoiceAllocator(testSampleRate, Voicing{Polyphony: 3})
+	notes := []Note{60, 62, 64, 65, 67}
+	for _, note := range notes {
+		allocator.noteOn(testInstrument{}, note, time.Second)
+		streamVoices(allocator)
+	}
+
+	// The two oldest notes were stolen and faded out within a buffer
+	expectNotes(t, allocator, 64, 65, 67)
+	if len(allocator.voices) != 3 {
+		t.Errorf("Expected stolen voices to be removed after their fade, got %d voices", len(allocator.voices))
+	}
+}
+
+func TestVoiceDefaultPolyphony(t *testing.T) {
+	allocator := newVoiceAllocator(testSampleRate, Voicing{})
+	for note := range Note(DefaultPolyphony + 2) {
+		allocator.noteOn(testInstrument{}, 60+note, time.Second)
+	}
+	if got := len(allocator.playing()); got != DefaultPolyphony {
+		t.Errorf("Expected %d voices, got %d", DefaultPolyphony, got)
+	}
+}
+
+func TestVoiceStealsQuietest(t *testing.T) {
+	instrument := testInstrument{levels: map[Note]float64{60: 0.9, 62: 0.1, 64: 0.5, 65: 0.7}}
+	allocator := newVoiceAllocator(testSampleRate, Voicing{Polyphony: 2, Steal: StealQuietest})
+
+	allocator.noteOn(instrument, 60, time.Second)
+	allocator.noteOn(instrument, 62, time.Second)
+	streamVoices(allocator)
+
+	// The quiet newer note is taken over rather than the loud older one
+	allocator.noteOn(instrument, 64, time.Second)
+	expectNotes(t, allocator, 60, 64)
+
+	streamVoices(allocator)
+	allocator.noteOn(instrument, 65, time.Second)
+	expectNotes(t, allocator, 60, 65)
+}
+
+func TestVoiceQuietestPrefersOldestOnEqualLevels(t *testing.T) {
+	allocator := newVoiceAllocator(testSampleRate, Voicing{Polyphony: 2, Steal: StealQuietest})
+	allocator.noteOn(testInstrument{}, 60, time.Second)
+	allocator.noteOn(testInstrument{}, 62, time.Second)
+	streamVoices(allocator)
+
+	allocator.noteOn(testInstrument{}, 64, time.Second)
+	expectNotes(t, allocator, 62, 64)
+}
+
+func TestVoiceLegato(t *testing.T) {
+	allocator := newVoiceAllocator(testSampleRate, Voicing{Polyphony: 4, Mode: Legato})
+
+	allocator.noteOn(testInstrument{}, 60, time.Second)
+	streamVoices(allocator)
+	allocator.noteOn(testInstrument{}, 62, time.Second)
+	allocator.noteOn(testInstrument{}, 64, time.Second)
+
+	// Legato plays one note at a time, handing over without a new attack
+	expectNotes(t, allocator, 64)
+	for i, v := range allocator.voices {
+		if legato := v.streamer.(*testVoice).legato; legato != (i > 0) {
+			t.Errorf("Expected only the first note to retrigger, voice %d legato is %v", i, legato)
+		}
+	}
+
+	streamVoices(allocator)
+	if len(allocator.voices) != 1 {
+		t.Errorf("Expected the handed over voices to fade out, got %d voices", len(allocator.voices))
+	}
+
+	// After all voices ended the next note retriggers
+	allocator.stop()
+	streamVoices(allocator)
+	allocator.noteOn(testInstrument{}, 65, time.Second)
+	if v := allocator.voices[len(allocator.voices)-1].streamer.(*testVoice); v.legato {
+		t.Error("Expected a note after silence to retrigger")
+	}
+}
+
+func TestVoiceRetriggerKeepsSoundingVoices(t *testing.T) {
+	allocator := newVoiceAllocator(testSampleRate, Voicing{Polyphony: 4, Mode: Retrigger})
+	allocator.noteOn(testInstrument{}, 60, time.Second)
+	allocator.noteOn(testInstrument{}, 64, time.Second)
+
+	expectNotes(t, allocator, 60, 64)
+	for _, v := range allocator.voices {
+		if v.streamer.(*testVoice).legato {
+			t.Error("Expected retriggered voices to start with their attack")
+		}
+	}
+}
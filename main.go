@@ -1,20 +1,24 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"math"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tetrackt/tetrackt/audio"
 	"github.com/tetrackt/tetrackt/persistence"
 	"github.com/tetrackt/tetrackt/ui"
 
+	"github.com/aymanbagabas/go-osc52/v2"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/gopxl/beep/v2"
-	"github.com/gopxl/beep/v2/effects"
 	"github.com/gopxl/beep/v2/speaker"
 )
 
@@ -28,6 +32,13 @@ const (
 	Oscillator2EditMode
 	Envelope2EditMode
 	MixerEditMode
+	MasterEditMode
+	EffectsEditMode
+	ConsoleMode
+	MacroEditMode
+	DrumEditMode
+	SfxEditMode
+	modeCount
 )
 
 var (
@@ -55,17 +66,34 @@ var (
 				Bold(true).
 				Padding(0, 2)
 
+	limitStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ffeb3b")).
+			Bold(true)
+
+	clipStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("#d50000")).
+			Foreground(lipgloss.Color("#ffffff")).
+			Bold(true)
+
 	modalBorderStyle = lipgloss.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(lipgloss.Color("#ff9800")).
 				Padding(0, 2)
 )
 
+// Octave setting range of the piano keys. Notes end at G-9, so in octave 9 the keys above G
+// and the whole upper piano row play nothing.
 const (
-	minOctave = 1
-	maxOctave = 8
+	minOctave = int(audio.MinOctave)
+	maxOctave = int(audio.MaxOctave)
 )
 
+// meterInterval is the refresh interval of the mixer console level meters
+const meterInterval = time.Millisecond * 50
+
+// humanizeSpread is how far humanizing moves the volume of a row up or down
+const humanizeSpread = 8
+
 // model represents the application state
 type model struct {
 	width       int
@@ -76,37 +104,67 @@ type model struct {
 	oscillator2 *ui.OscillatorModel
 	envelope2   *ui.EnvelopeModel
 	mixer       *ui.Mixer
+	master      *ui.MasterModel
+	effects     *ui.EffectsModel
+	console     *ui.ConsoleModel
+	macros      *ui.MacroModel
+	drum        *ui.DrumModel
+	sfx         *ui.SfxModel
 	tracker     *ui.TrackerModel
+	engine      *audio.Engine
+	history     *ui.History
+	clipboard   ui.Clipboard
 
 	mode InputMode
 
+	// effectsBus is the number of the bus edited in the effects panel, 0 edits the current track
+	effectsBus int
+
 	octave       int
 	globalVolume float64
 
+	// key is used by the scale aware transpose of the selection
+	key audio.Key
+
+	// keymap binds keys to actions, keyboard is the layout of the piano keys in the pattern editor
+	keymap   *ui.Keymap
+	keyboard ui.KeyboardLayout
+
+	// vim navigates the pattern with vim keys when the keymap uses the vim profile, nil otherwise
+	vim *ui.Vim
+
+	// output stage status, refreshed on every playback tick
+	limiting bool
+	clipped  bool
+
+	// metering is true while meter updates are scheduled for the mixer console
+	metering bool
+
 	// file dialog
 	fileDialog *ui.FileDialogModel
 	// current loaded/saved filename (prefill on save)
 	currentFilename string
+	// exporting is the WAV file being rendered in the background, empty when idle
+	exporting string
+
+	// command line opened with :
+	commandLine *ui.CommandLineModel
+
+	// output is the terminal the program renders to, shared with escape sequences of commands
+	output *terminalOutput
 }
 
 // tickMsg is sent to advance playback
 type tickMsg time.Time
 
-var noteKeyToName = map[string]audio.Base{
-	"1":  "C",
-	"!":  "C#",
-	"2":  "D",
-	"@":  "D#",
-	"\"": "D#", // german keyboard layout
-	"3":  "E",
-	"4":  "F",
-	"$":  "F#",
-	"5":  "G",
-	"%":  "G#",
-	"6":  "A",
-	"^":  "A#",
-	"&":  "A#", // german keyboard layout
-	"7":  "B",
+// meterMsg is sent to refresh the level meters of the mixer console
+type meterMsg time.Time
+
+// exportedMsg is sent when a WAV export rendered in the background has finished
+type exportedMsg struct {
+	mode     ui.FileDialogMode
+	filename string
+	err      error
 }
 
 func (m model) Init() tea.Cmd {
@@ -115,6 +173,7 @@ func (m model) Init() tea.Cmd {
 	buffersize := sampleRate.N(time.Millisecond * 250)
 
 	speaker.Init(sampleRate, buffersize)
+	speaker.Play(m.engine)
 
 	return nil
 }
@@ -130,166 +189,79 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
-		// Global mode switching
-		switch keyStr := msg.String(); keyStr {
-		case "s":
-			// Open save dialog
-			prefill := "song"
-			if m.currentFilename != "" {
-				prefill = m.currentFilename
-			}
-			m.fileDialog.Show(ui.ModeSave, prefill)
-			return m, nil
-		case "l":
-			// Open load dialog
-			m.fileDialog.Show(ui.ModeLoad, "")
-			return m, nil
-		case "o":
-			switch m.mode {
-			case Oscillator1EditMode:
-				m.mode = Oscillator2EditMode
-			case Oscillator2EditMode:
-				m.mode = Oscillator1EditMode
-			default:
-				m.mode = Oscillator1EditMode
-			}
-
-			return m, nil
-		case "t":
-			m.mode = TrackMode
-			return m, nil
-		case "e":
-			switch m.mode {
-			case Envelope1EditMode:
-				m.mode = Envelope2EditMode
-			case Envelope2EditMode:
-				m.mode = Envelope1EditMode
-			default:
-				m.mode = Envelope1EditMode
-			}
-
-			return m, nil
-		case "delete":
-			// TODO: KeyMsg should be handled by the tracker
-			m.tracker.SetNote(audio.Off())
-
-		case "+":
-			if m.octave < maxOctave {
-				m.octave++
-			}
-
-			note := m.tracker.GetNote()
-			if newNote, ok := note.Transpose(-1); ok {
-				m.tracker.SetNote(newNote)
-				m.playNote(newNote)
-				return m, nil
-			}
+		// The command line takes all keys while open
+		if m.commandLine.IsVisible() {
+			var cmd tea.Cmd
+			*m.commandLine, cmd = m.commandLine.Update(msg)
+			return m, cmd
+		}
 
-			return m, nil
-		case "-":
-			if m.octave > minOctave {
-				m.octave--
-			}
+		// The envelope presets take all keys while open
+		if m.presetsOpen() {
+			return m, m.updatePanel(msg)
+		}
 
-			note := m.tracker.CurrentTrack().CurrentRow().Note
-			if newNote, ok := note.Transpose(-1); ok {
-				m.tracker.SetNote(newNote)
-				m.playNote(newNote)
-				return m, nil
+		// Text pasted into the terminal is read as rows in the clipboard text format
+		if msg.Paste {
+			if m.mode == TrackMode {
+				m.pasteText(string(msg.Runes))
 			}
-
 			return m, nil
-		// volume
-		case "[", "alt+[": // decrease volume, for german keyboard layout we need to consider the alt+combo
-			m.globalVolume -= 0.05
-			if m.globalVolume < 0.0 {
-				m.globalVolume = 0.0
-			}
+		}
 
-			m.mixer.GlobalVolume = m.globalVolume
-			return m, nil
-		case "]", "alt+]": // increase volume, for german keyboard layout we need to consider the alt+combo
-			m.globalVolume += 0.05
-			if m.globalVolume > 1.0 {
-				m.globalVolume = 1.0
+		// The vim normal mode navigates the pattern before any other key handling
+		if m.mode == TrackMode && m.vim != nil {
+			if command, cmd, ok := m.vim.Handle(msg.String(), m.tracker, &m.clipboard); ok {
+				m.history.Record(command)
+				return m, cmd
 			}
+		}
 
-			m.mixer.GlobalVolume = m.globalVolume
-			return m, nil
-		case "tab":
-			m.mode = InputMode((int(m.mode) + 1) % 6) // Cycle through 6 modes
-			return m, nil
-		case "shift+tab":
-			m.mode = InputMode((int(m.mode) - 1) % 6) // Cycle through 6 modes
-			if m.mode < 0 {
-				m.mode += 6
+		// In the volume and effect columns typed characters are values, not notes or mode keys,
+		// characters invalid for the column are ignored. Only other keys reach the key bindings.
+		if m.entering() && m.tracker.CursorColumn != ui.NoteColumn && msg.Type == tea.KeyRunes && !msg.Alt {
+			if len(msg.Runes) == 1 {
+				m.enterValue(msg.Runes[0])
 			}
 			return m, nil
-		case "p", "P":
-			// Toggle play/pause
-			m.tracker.IsPlaying = !m.tracker.IsPlaying
-			m.tracker.LoopToRow = false // normal play toggles off loop mode
-			if m.tracker.IsPlaying {
-				m.tracker.PlaybackRow = 0
-
-				// TODO: Loop to row is just a special play mode, that does not use 0..numRows range
-				if "P" == keyStr {
-					m.tracker.LoopToRow = true
-					m.tracker.LoopEndRow = m.tracker.CursorRow
-				}
-
-				// TODO: Refactor to have a play command returned from tracker.Update
-				return m, m.tick()
-			} else {
-				//speaker.Clear()
-			}
-		case "q", "ctrl+c":
-			speaker.Clear()
-			return m, tea.Quit
 		}
 
-		// Global note playing (available in any mode)
-		if base, ok := noteKeyToName[msg.String()]; ok {
-			note := audio.Note{Base: base, Octave: audio.Octave(m.octave)}
-			m.playNote(note)
-
-			if m.mode == TrackMode {
-				m.tracker.SetNote(note)
+		// In the pattern editor the piano keyboard takes precedence over the single key shortcuts
+		if m.entering() {
+			if semitone, ok := m.keyboard.Semitone(msg.String()); ok {
+				m.enterNote(semitone)
+				return m, nil
 			}
-
-			return m, nil
 		}
 
-		if m.mode == Envelope1EditMode {
-			var _, cmd = m.envelope1.Update(msg)
-			return m, cmd
+		// Key bindings of the mode and the global ones, the panel actions go to the panel of the mode
+		if action, ok := m.keymap.Action(m.keyContext(), msg.String()); ok {
+			if cmd, ok := m.runAction(action); ok {
+				return m, cmd
+			}
+			return m, m.updatePanel(ui.ActionMsg{Action: action})
 		}
 
-		if m.mode == Envelope2EditMode {
-			var _, cmd = m.envelope2.Update(msg)
-			return m, cmd
+		// The other modes play notes on the digits to audition the instrument
+		if semitone, ok := ui.DigitsLayout.Semitone(msg.String()); ok {
+			m.enterNote(semitone)
+			return m, nil
 		}
 
-		// Handle oscillator edit mode
-		if m.mode == Oscillator1EditMode {
-			var _, cmd = m.oscillator1.Update(msg)
-			return m, cmd
-		}
+		return m, m.updatePanel(msg)
 
-		if m.mode == Oscillator2EditMode {
-			var _, cmd = m.oscillator2.Update(msg)
-			return m, cmd
+	case meterMsg:
+		if m.mode != ConsoleMode {
+			m.metering = false
+			return m, nil
 		}
 
-		if m.mode == MixerEditMode {
-			var _, cmd = m.mixer.Update(msg)
-			return m, cmd
-		}
+		speaker.Lock()
+		levels := m.engine.Levels()
+		speaker.Unlock()
 
-		if m.mode == TrackMode {
-			var _, cmd = m.tracker.Update(msg)
-			return m, cmd
-		}
+		m.console.SetLevels(levels)
+		return m, m.meterTick()
 
 	case tickMsg:
 		if !m.tracker.IsPlaying {
@@ -299,6 +271,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Play all notes at current playback row
 		m.playRowNotes(m.tracker.PlaybackRow)
 
+		speaker.Lock()
+		m.limiting, m.clipped = m.engine.ClipStatus()
+		speaker.Unlock()
+
 		// Advance to next row
 		m.tracker.PlaybackRow++
 		if m.tracker.LoopToRow {
@@ -336,6 +312,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.envelope2.Envelope = msg.Envelope2
 		m.oscillator2.Oscillator = msg.Oscillator2
 		m.mixer.Mixer = msg.Mixer
+		m.mixer.Channel = msg.Channel
+		m.macros.SetMacros(msg.Macros)
+		m.drum.Instrument = msg.Instrument
+		m.drum.Drum = msg.Drum
+		m.sfx.Instrument = msg.Instrument
+		m.sfx.Sfx = msg.Sfx
+		m.console.Current = m.tracker.CursorTrack
+		m.effectsBus = 0
+		m.syncEffects()
+
+	case exportedMsg:
+		m.exporting = ""
+		if msg.err != nil {
+			m.fileDialog.Show(msg.mode, msg.filename)
+			m.fileDialog.SetError(fmt.Sprintf("Export failed: %v", msg.err))
+		}
 
 	case ui.FileDialogConfirmed:
 		// Handle file dialog confirmation
@@ -359,12 +351,53 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				// Update existing tracker model instead of creating new one
 				persistence.SongToTracks(song, m.tracker)
+				m.history.Clear()
+				m.effectsBus = 0
 				m.currentFilename = filename
 				m.fileDialog.Hide()
+				return m, m.syncSong()
+			}
+		case ui.ModeExport:
+			// Render song offline
+			if m.exporting != "" {
+				m.fileDialog.SetError(fmt.Sprintf("Export to %s in progress", m.exporting))
+			} else {
+				return m, m.export(filename, m.renderSong())
+			}
+		case ui.ModeSfx:
+			// Export the sound effect of the current track as one shot
+			if m.exporting != "" {
+				m.fileDialog.SetError(fmt.Sprintf("Export to %s in progress", m.exporting))
+			} else {
+				sfx := audio.NewSfxSynth(m.sampleRate, m.sfx.Sfx, m.tracker.Tuning)
+				return m, m.export(filename, sfx.OneShot())
+			}
+		case ui.ModeTuning:
+			// Load Scala scale or keyboard mapping
+			tuning, err := persistence.LoadTuningFile(filename, m.tracker.Tuning)
+			if err != nil {
+				m.fileDialog.SetError(fmt.Sprintf("Tuning failed: %v", err))
+			} else {
+				m.recordSong(func() {
+					m.tracker.Tuning = tuning
+				})
+				m.master.Tuning = tuning
+				m.fileDialog.Hide()
 			}
 		}
 		return m, nil
 
+	case ui.CommandEntered:
+		// Errors keep the command line open with the command for correction
+		input := m.commandLine.Input
+		m.commandLine.Hide()
+		cmd, err := m.runCommand(msg)
+		if err != nil {
+			m.commandLine.Show(input)
+			m.commandLine.SetError(err.Error())
+		}
+		return m, cmd
+
 	case ui.FileDialogCancelled:
 		// Handle file dialog cancellation
 		m.fileDialog.Hide()
@@ -372,22 +405,94 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case ui.OscillatorUpdated:
 		// TODO: Refactor to allow updating via a method instead of direct field access
-		switch m.mode {
-		case Oscillator1EditMode:
-			m.tracker.Tracks[m.tracker.CursorTrack].Oscillator1 = msg.Oscillator
-		case Oscillator2EditMode:
-			m.tracker.Tracks[m.tracker.CursorTrack].Oscillator2 = msg.Oscillator
-		}
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			switch m.mode {
+			case Oscillator1EditMode:
+				m.tracker.Tracks[m.tracker.CursorTrack].Oscillator1 = msg.Oscillator
+			case Oscillator2EditMode:
+				m.tracker.Tracks[m.tracker.CursorTrack].Oscillator2 = msg.Oscillator
+			}
+		})
 	case ui.EnvelopeUpdated:
 		// TODO: Refactor to allow updating via a method instead of direct field access
-		switch m.mode {
-		case Envelope1EditMode:
-			m.tracker.Tracks[m.tracker.CursorTrack].Envelope1 = msg.Envelope
-		case Envelope2EditMode:
-			m.tracker.Tracks[m.tracker.CursorTrack].Envelope2 = msg.Envelope
-		}
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			switch m.mode {
+			case Envelope1EditMode:
+				m.tracker.Tracks[m.tracker.CursorTrack].Envelope1 = msg.Envelope
+			case Envelope2EditMode:
+				m.tracker.Tracks[m.tracker.CursorTrack].Envelope2 = msg.Envelope
+			}
+		})
 	case ui.MixerUpdated:
-		m.tracker.Tracks[m.tracker.CursorTrack].Mixer = msg.Mixer
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			m.tracker.Tracks[m.tracker.CursorTrack].Mixer = msg.Mixer
+			m.setChannel(m.tracker.CursorTrack, msg.Channel)
+		})
+	case ui.MacrosUpdated:
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			m.tracker.Tracks[m.tracker.CursorTrack].Macros = msg.Macros
+		})
+	case ui.DrumUpdated:
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			m.tracker.Tracks[m.tracker.CursorTrack].Drum = msg.Drum
+			m.setInstrument(msg.Instrument)
+		})
+	case ui.SfxUpdated:
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			m.tracker.Tracks[m.tracker.CursorTrack].Sfx = msg.Sfx
+			m.setInstrument(msg.Instrument)
+		})
+		if msg.Play {
+			m.playNote(audio.NewNote(audio.BaseC, audio.Octave4))
+		}
+	case ui.SfxExportRequested:
+		m.fileDialog.Show(ui.ModeSfx, string(msg.Sfx.Category))
+	case ui.EffectsUpdated:
+		if m.effectsBus > 0 && m.effectsBus <= len(m.tracker.Buses) {
+			m.recordSong(func() {
+				bus := m.tracker.Buses[m.effectsBus-1]
+				bus.Inserts = msg.Inserts
+				m.setBus(m.effectsBus-1, bus)
+			})
+			return m, nil
+		}
+
+		m.recordTrack(m.tracker.CursorTrack, func() {
+			channel := m.tracker.Tracks[m.tracker.CursorTrack].Channel
+			channel.Inserts = msg.Inserts
+			m.setChannel(m.tracker.CursorTrack, channel)
+		})
+	case ui.ChannelUpdated:
+		m.recordTrack(msg.Track, func() {
+			m.setChannel(msg.Track, msg.Channel)
+		})
+	case ui.BusUpdated:
+		m.recordSong(func() {
+			m.setBus(msg.Index, msg.Bus)
+		})
+	case ui.BusesChanged:
+		m.recordSong(func() {
+			m.tracker.Buses = msg.Buses
+			if m.effectsBus > len(m.tracker.Buses) {
+				m.effectsBus = 0
+				m.syncEffects()
+			}
+			m.setBuses()
+			for track, channel := range msg.Channels {
+				m.setChannel(track, channel)
+			}
+		})
+	case ui.ChannelSelected:
+		return m, m.tracker.SelectTrack(msg.Track)
+	case ui.MasterUpdated:
+		m.recordSong(func() {
+			m.tracker.Master = msg.Master
+			m.setMaster(msg.Master)
+		})
+	case ui.TuningUpdated:
+		m.recordSong(func() {
+			m.tracker.Tuning = msg.Tuning
+		})
 	}
 
 	return m, nil
@@ -395,90 +500,744 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // tick returns a command that sends a tickMsg after a delay
 func (m *model) tick() tea.Cmd {
-	return tea.Tick(time.Millisecond*250, func(t time.Time) tea.Msg {
+	return tea.Tick(m.tracker.RowDuration(), func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
+// meterTick returns a command that sends a meterMsg after a delay
+func (m *model) meterTick() tea.Cmd {
+	return tea.Tick(meterInterval, func(t time.Time) tea.Msg {
+		return meterMsg(t)
+	})
+}
+
+// startMetering schedules meter updates when the mixer console is shown and no updates are scheduled yet
+func (m *model) startMetering() tea.Cmd {
+	if m.mode != ConsoleMode || m.metering {
+		return nil
+	}
+
+	m.metering = true
+	return m.meterTick()
+}
+
+// syncConsole refreshes the mixer console with the channels of all tracks and the buses
+func (m *model) syncConsole() {
+	m.console.Channels = trackChannels(m.tracker)
+	m.console.Buses = m.tracker.Buses
+	if m.console.SelectedBus() == 0 {
+		m.console.Current = m.tracker.CursorTrack
+	}
+}
+
+// syncEffects shows the insert chain of the edited bus or the current track in the effects panel
+func (m *model) syncEffects() {
+	if m.effectsBus > 0 && m.effectsBus <= len(m.tracker.Buses) {
+		bus := m.tracker.Buses[m.effectsBus-1]
+		m.effects.SetInserts(bus.Inserts)
+		m.effects.Bus = bus.Name
+		return
+	}
+
+	m.effects.SetInserts(m.tracker.CurrentTrack().Channel.Inserts)
+	m.effects.Bus = ""
+}
+
+// setNote sets the note at the cursor as undoable edit
+func (m *model) setNote(note audio.Note) {
+	track, row := m.tracker.CursorTrack, m.tracker.CursorRow
+	before := m.tracker.Tracks[track].Rows[row]
+	after := m.tracker.SetNote(note)
+	m.history.Record(ui.RowEdit(track, row, before, after))
+}
+
+// togglePlay starts playback from the first row or stops it, looping up to the cursor row when loop is set
+func (m *model) togglePlay(loop bool) tea.Cmd {
+	m.tracker.IsPlaying = !m.tracker.IsPlaying
+	m.tracker.LoopToRow = false // normal play toggles off loop mode
+	if m.tracker.IsPlaying {
+		m.tracker.PlaybackRow = 0
+
+		// TODO: Loop to row is just a special play mode, that does not use 0..numRows range
+		if loop {
+			m.tracker.LoopToRow = true
+			m.tracker.LoopEndRow = m.tracker.CursorRow
+		}
+
+		// TODO: Refactor to have a play command returned from tracker.Update
+		return m.tick()
+	}
+
+	speaker.Lock()
+	m.engine.Stop()
+	speaker.Unlock()
+	return nil
+}
+
+// runAction runs an action of the key bindings or the command line, reporting false for unknown actions
+func (m *model) runAction(action ui.Action) (tea.Cmd, bool) {
+	switch action {
+	case ui.ActionSave:
+		// Open save dialog
+		prefill := "song"
+		if m.currentFilename != "" {
+			prefill = m.currentFilename
+		}
+		m.fileDialog.Show(ui.ModeSave, prefill)
+		return nil, true
+	case ui.ActionLoad:
+		// Open load dialog
+		m.fileDialog.Show(ui.ModeLoad, "")
+		return nil, true
+	case ui.ActionExport:
+		// Open export dialog
+		prefill := strings.TrimSuffix(m.currentFilename, ".yaml")
+		if prefill == "" {
+			prefill = "song"
+		}
+		m.fileDialog.Show(ui.ModeExport, prefill)
+		return nil, true
+	case ui.ActionTuning:
+		// Open Scala tuning dialog
+		m.fileDialog.Show(ui.ModeTuning, "")
+		return nil, true
+	case ui.ActionOscillator:
+		switch m.mode {
+		case Oscillator1EditMode:
+			m.mode = Oscillator2EditMode
+		case Oscillator2EditMode:
+			m.mode = Oscillator1EditMode
+		default:
+			m.mode = Oscillator1EditMode
+		}
+
+		return nil, true
+	case ui.ActionTrack:
+		m.mode = TrackMode
+		return nil, true
+	case ui.ActionMaster:
+		m.mode = MasterEditMode
+		return nil, true
+	case ui.ActionEffects:
+		// From the mixer console a selected bus strip opens the effects of that bus
+		m.effectsBus = 0
+		if m.mode == ConsoleMode {
+			m.syncConsole()
+			m.effectsBus = m.console.SelectedBus()
+		}
+		m.syncEffects()
+		m.mode = EffectsEditMode
+		return nil, true
+	case ui.ActionConsole:
+		m.mode = ConsoleMode
+		return m.startMetering(), true
+	case ui.ActionMacros:
+		m.mode = MacroEditMode
+		return nil, true
+	case ui.ActionDrum:
+		m.mode = DrumEditMode
+		return nil, true
+	case ui.ActionSfx:
+		m.mode = SfxEditMode
+		return nil, true
+	case ui.ActionEnvelope:
+		switch m.mode {
+		case Envelope1EditMode:
+			m.mode = Envelope2EditMode
+		case Envelope2EditMode:
+			m.mode = Envelope1EditMode
+		default:
+			m.mode = Envelope1EditMode
+		}
+
+		return nil, true
+	case ui.ActionClear:
+		// TODO: KeyMsg should be handled by the tracker
+		m.clearColumn()
+		return nil, true
+	case ui.ActionInsertRow, ui.ActionInsertRowAll:
+		m.history.Record(m.tracker.InsertRow(action == ui.ActionInsertRowAll))
+		return nil, true
+	case ui.ActionDeleteRow, ui.ActionDeleteRowAll:
+		m.history.Record(m.tracker.DeleteRow(action == ui.ActionDeleteRowAll))
+		return nil, true
+	case ui.ActionEditStepDown:
+		m.tracker.AdjustEditStep(-1)
+		return nil, true
+	case ui.ActionEditStepUp:
+		m.tracker.AdjustEditStep(1)
+		return nil, true
+	case ui.ActionOctaveUp:
+		if m.octave < maxOctave {
+			m.octave++
+		}
+
+		note := m.tracker.GetNote()
+		if newNote, ok := note.Transpose(audio.SemitonesPerOctave); ok {
+			m.setNote(newNote)
+			m.playNote(newNote)
+			return nil, true
+		}
+
+		return nil, true
+	case ui.ActionOctaveDown:
+		if m.octave > minOctave {
+			m.octave--
+		}
+
+		note := m.tracker.CurrentTrack().CurrentRow().Note
+		if newNote, ok := note.Transpose(-audio.SemitonesPerOctave); ok {
+			m.setNote(newNote)
+			m.playNote(newNote)
+			return nil, true
+		}
+
+		return nil, true
+	// volume
+	case ui.ActionVolumeDown:
+		m.globalVolume -= 0.05
+		if m.globalVolume < 0.0 {
+			m.globalVolume = 0.0
+		}
+
+		m.mixer.GlobalVolume = m.globalVolume
+		m.setVolume(m.globalVolume)
+		return nil, true
+	case ui.ActionVolumeUp:
+		m.globalVolume += 0.05
+		if m.globalVolume > 1.0 {
+			m.globalVolume = 1.0
+		}
+
+		m.mixer.GlobalVolume = m.globalVolume
+		m.setVolume(m.globalVolume)
+		return nil, true
+	case ui.ActionNextMode:
+		m.mode = (m.mode + 1) % modeCount // Cycle through all modes
+		return m.startMetering(), true
+	case ui.ActionPreviousMode:
+		m.mode = (m.mode - 1 + modeCount) % modeCount // Cycle through all modes
+		return m.startMetering(), true
+	case ui.ActionPlay, ui.ActionLoop:
+		return m.togglePlay(action == ui.ActionLoop), true
+	case ui.ActionCopy, ui.ActionCut, ui.ActionPaste, ui.ActionPasteOverwrite, ui.ActionPasteMix:
+		return m.blockCommand(action), true
+	// Selection transforms
+	case ui.ActionTransposeUp, ui.ActionTransposeDown, ui.ActionTransposeOctaveUp, ui.ActionTransposeOctaveDown,
+		ui.ActionTransposeKeyUp, ui.ActionTransposeKeyDown, ui.ActionReverse, ui.ActionInvert,
+		ui.ActionInterpolate, ui.ActionHumanize:
+		m.history.Record(m.transformCommand(action))
+		return nil, true
+	case ui.ActionKeyboard:
+		idx := max(slices.Index(ui.KeyboardLayouts, m.keyboard), 0)
+		m.keyboard = ui.KeyboardLayouts[(idx+1)%len(ui.KeyboardLayouts)]
+		return nil, true
+	case ui.ActionKeyMode:
+		m.key.Mode = cycleKeyMode(m.key.Mode)
+		return nil, true
+	case ui.ActionKeyRoot:
+		m.key.Root = cycleKeyRoot(m.key.Root)
+		return nil, true
+	case ui.ActionUndo:
+		if m.history.Undo(m.tracker) {
+			return m.syncSong(), true
+		}
+		return nil, true
+	case ui.ActionRedo:
+		if m.history.Redo(m.tracker) {
+			return m.syncSong(), true
+		}
+		return nil, true
+	case ui.ActionQuit:
+		speaker.Clear()
+		return tea.Quit, true
+	case ui.ActionCommand:
+		m.commandLine.Show("")
+		return nil, true
+	case ui.ActionJump:
+		m.commandLine.Show("goto ")
+		return nil, true
+	}
+
+	return nil, false
+}
+
+// commands are the command line commands besides the actions of the key bindings
+var commands = []string{"bpm", "rows", "goto", "transpose", "w", "e", "q"}
+
+// commandNames returns the names the command line completes
+func commandNames(keymap *ui.Keymap) []string {
+	names := slices.Clone(commands)
+	for _, action := range keymap.Actions() {
+		names = append(names, string(action))
+	}
+	return names
+}
+
+// runCommand runs a command of the command line, other names run the action of the key bindings
+func (m *model) runCommand(command ui.CommandEntered) (tea.Cmd, error) {
+	args := command.Args
+	switch command.Name {
+	case "bpm":
+		bpm, err := intArg(args, "bpm <tempo>")
+		if err != nil {
+			return nil, err
+		}
+		m.recordSong(func() {
+			err = m.tracker.SetBPM(bpm)
+		})
+		m.setRowDuration()
+		return nil, err
+	case "rows":
+		rows, err := intArg(args, "rows <count>")
+		if err != nil {
+			return nil, err
+		}
+		resize, err := m.tracker.Resize(rows)
+		m.history.Record(resize)
+		return nil, err
+	case "goto":
+		row, err := intArg(args, "goto <row>, e.g. goto 48 or goto 0x30")
+		if err != nil {
+			return nil, err
+		}
+		if row < 0 || row >= m.tracker.NumRows {
+			return nil, fmt.Errorf("row must be between 0 and %d", m.tracker.NumRows-1)
+		}
+		m.mode = TrackMode
+		m.tracker.GoToRow(row)
+		return nil, nil
+	case "transpose":
+		semitones, err := intArg(args, "transpose <semitones>, e.g. transpose +3")
+		if err != nil {
+			return nil, err
+		}
+		m.history.Record(m.tracker.Transpose(semitones))
+		return nil, nil
+	case "w":
+		if len(args) > 1 {
+			return nil, errors.New("usage: w [file]")
+		}
+		filename := m.currentFilename
+		if len(args) == 1 {
+			filename = args[0]
+		}
+		if filename == "" {
+			cmd, _ := m.runAction(ui.ActionSave)
+			return cmd, nil
+		}
+		return m.fileDialog.Submit(ui.ModeSave, filename), nil
+	case "e":
+		if len(args) != 1 {
+			return nil, errors.New("usage: e <file>")
+		}
+		return m.fileDialog.Submit(ui.ModeLoad, args[0]), nil
+	case "q":
+		command.Name = string(ui.ActionQuit)
+	}
+
+	action := ui.Action(command.Name)
+	if !slices.Contains(m.keymap.Actions(), action) {
+		return nil, fmt.Errorf("unknown command %q", command.Name)
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%s takes no arguments", action)
+	}
+	if cmd, ok := m.runAction(action); ok {
+		return cmd, nil
+	}
+	if !slices.Contains(m.keymap.Actions(m.keyContext()), action) {
+		return nil, fmt.Errorf("%s is not available in this mode", action)
+	}
+	return m.updatePanel(ui.ActionMsg{Action: action}), nil
+}
+
+// intArg parses the single argument of a command as decimal or 0x prefixed hex number
+func intArg(args []string, usage string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: %s", usage)
+	}
+
+	arg, base := args[0], 10
+	if hex, ok := strings.CutPrefix(strings.ToLower(arg), "0x"); ok {
+		arg, base = hex, 16
+	}
+	n, err := strconv.ParseInt(arg, base, 0)
+	if err != nil {
+		return 0, fmt.Errorf("usage: %s", usage)
+	}
+	return int(n), nil
+}
+
+// updatePanel passes a message to the panel of the current mode, the actions bound in
+// the panel contexts arrive as ui.ActionMsg
+func (m *model) updatePanel(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch m.mode {
+	case Envelope1EditMode:
+		_, cmd = m.envelope1.Update(msg)
+	case Envelope2EditMode:
+		_, cmd = m.envelope2.Update(msg)
+	case Oscillator1EditMode:
+		_, cmd = m.oscillator1.Update(msg)
+	case Oscillator2EditMode:
+		_, cmd = m.oscillator2.Update(msg)
+	case MixerEditMode:
+		_, cmd = m.mixer.Update(msg)
+	case MasterEditMode:
+		_, cmd = m.master.Update(msg)
+	case EffectsEditMode:
+		_, cmd = m.effects.Update(msg)
+	case MacroEditMode:
+		_, cmd = m.macros.Update(msg)
+	case DrumEditMode:
+		_, cmd = m.drum.Update(msg)
+	case SfxEditMode:
+		_, cmd = m.sfx.Update(msg)
+	case ConsoleMode:
+		m.syncConsole()
+		_, cmd = m.console.Update(msg)
+	case TrackMode:
+		_, cmd = m.tracker.Update(msg)
+	}
+	return cmd
+}
+
+// enterNote plays the note semitones above C of the octave setting,
+// in the pattern editor it is also set at the cursor
+func (m *model) enterNote(semitone int) {
+	note, ok := audio.NewNote(audio.BaseC, audio.Octave(m.octave)).Transpose(semitone)
+	if !ok {
+		// Above the highest playable note
+		return
+	}
+	m.playNote(note)
+
+	if m.mode == TrackMode {
+		m.setNote(note)
+		m.tracker.Advance()
+	}
+}
+
+// enterValue types r into the volume or effect column at the cursor as undoable edit,
+// reporting whether r was a valid value for the column
+func (m *model) enterValue(r rune) bool {
+	track, row := m.tracker.CursorTrack, m.tracker.CursorRow
+	before := m.tracker.Tracks[track].Rows[row]
+	after, ok := m.tracker.EnterValue(r)
+	if ok {
+		m.history.Record(ui.RowEdit(track, row, before, after))
+	}
+	return ok
+}
+
+// clearColumn empties the column at the cursor as undoable edit
+func (m *model) clearColumn() {
+	track, row := m.tracker.CursorTrack, m.tracker.CursorRow
+	before := m.tracker.Tracks[track].Rows[row]
+	after := m.tracker.ClearColumn()
+	m.history.Record(ui.RowEdit(track, row, before, after))
+}
+
+// blockCommand copies, cuts or pastes the selected block of the pattern,
+// copied rows also go to the clipboard of the terminal
+func (m *model) blockCommand(action ui.Action) tea.Cmd {
+	switch action {
+	case ui.ActionCopy:
+		m.clipboard = m.tracker.Copy()
+		m.tracker.ClearSelection()
+		return m.hostClipboard(m.clipboard.Text())
+	case ui.ActionCut:
+		var command ui.Command
+		m.clipboard, command = m.tracker.Cut()
+		m.history.Record(command)
+		return m.hostClipboard(m.clipboard.Text())
+	case ui.ActionPaste:
+		m.history.Record(m.tracker.Paste(m.clipboard, ui.PasteInsert))
+	case ui.ActionPasteOverwrite:
+		m.history.Record(m.tracker.Paste(m.clipboard, ui.PasteOverwrite))
+	case ui.ActionPasteMix:
+		m.history.Record(m.tracker.Paste(m.clipboard, ui.PasteMix))
+	}
+	return nil
+}
+
+// transformCommand applies a transform to the selected block and returns the command to undo it
+func (m *model) transformCommand(action ui.Action) ui.Command {
+	switch action {
+	case ui.ActionTransposeUp:
+		return m.tracker.Transpose(1)
+	case ui.ActionTransposeDown:
+		return m.tracker.Transpose(-1)
+	case ui.ActionTransposeOctaveUp:
+		return m.tracker.Transpose(audio.SemitonesPerOctave)
+	case ui.ActionTransposeOctaveDown:
+		return m.tracker.Transpose(-audio.SemitonesPerOctave)
+	case ui.ActionTransposeKeyUp:
+		return m.tracker.TransposeInKey(m.key, 1)
+	case ui.ActionTransposeKeyDown:
+		return m.tracker.TransposeInKey(m.key, -1)
+	case ui.ActionReverse:
+		return m.tracker.Reverse()
+	case ui.ActionInvert:
+		return m.tracker.Invert()
+	case ui.ActionInterpolate:
+		return m.tracker.InterpolateVolume()
+	case ui.ActionHumanize:
+		return m.tracker.HumanizeVolume(humanizeSpread)
+	}
+	return nil
+}
+
+// cycleKeyMode returns the key mode following mode
+func cycleKeyMode(mode audio.KeyMode) audio.KeyMode {
+	idx := max(slices.Index(audio.KeyModes, mode), 0)
+	return audio.KeyModes[(idx+1)%len(audio.KeyModes)]
+}
+
+// cycleKeyRoot returns the root a semitone above root
+func cycleKeyRoot(root audio.Base) audio.Base {
+	if root == "" {
+		root = audio.BaseC
+	}
+	note, _ := audio.NewNote(root, audio.Octave4).Transpose(1)
+	return note.Base()
+}
+
+// pasteText overwrites the rows at the cursor with pasted text, text that is not
+// in the clipboard format is ignored
+func (m *model) pasteText(text string) {
+	clipboard, err := ui.ParseClipboard(text)
+	if err != nil {
+		return
+	}
+	m.clipboard = clipboard
+	m.history.Record(m.tracker.Paste(clipboard, ui.PasteOverwrite))
+}
+
+// terminalOutput is the program output shared by the renderer and the escape sequences
+// written from commands. Writes are serialized so a sequence never lands inside a frame.
+type terminalOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+// Write implements io.Writer
+func (o *terminalOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+// WriteString implements io.StringWriter, which the renderer prefers over Write
+func (o *terminalOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// hostClipboard returns a command copying text to the system clipboard with an OSC 52
+// escape sequence written to the program output, which also reaches the local clipboard
+// over SSH. It is a no-op when the output is redirected and not a terminal.
+func (m model) hostClipboard(text string) tea.Cmd {
+	output := m.output
+	if output == nil || !term.IsTerminal(output.Fd()) {
+		return nil
+	}
+
+	return func() tea.Msg {
+		sequence := osc52.New(text)
+		if os.Getenv("TMUX") != "" {
+			sequence = sequence.Tmux()
+		} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+			sequence = sequence.Screen()
+		}
+		sequence.WriteTo(output)
+		return nil
+	}
+}
+
+// recordTrack records the changes edit makes to the settings of a track as undoable edit
+func (m *model) recordTrack(track int, edit func()) {
+	before := m.tracker.Tracks[track]
+	edit()
+	m.history.Record(ui.TrackEdit(track, before, m.tracker.Tracks[track]))
+}
+
+// recordSong records the changes edit makes to the song wide settings as undoable edit
+func (m *model) recordSong(edit func()) {
+	before := m.tracker.SongSettings()
+	edit()
+	m.history.Record(ui.SongEdit(before, m.tracker.SongSettings()))
+}
+
+// syncSong applies the whole song to the live engine and all panels after it changed
+// outside of the panels, e.g. by loading or undo, returning the command refreshing the track panels
+func (m *model) syncSong() tea.Cmd {
+	if m.effectsBus > len(m.tracker.Buses) {
+		m.effectsBus = 0
+	}
+	m.syncEffects()
+	m.setBuses()
+	m.setChannels()
+	m.master.Master = m.tracker.Master
+	m.master.Tuning = m.tracker.Tuning
+	m.setMaster(m.tracker.Master)
+	m.setRowDuration()
+	return m.tracker.SelectTrack(m.tracker.CursorTrack)
+}
+
+// setInstrument stores the instrument type of the current track and keeps the drum and
+// sound effect panels in sync, a track first switched to sound effects gets a generated one
+func (m *model) setInstrument(instrument audio.InstrumentType) {
+	track := &m.tracker.Tracks[m.tracker.CursorTrack]
+	track.Instrument = instrument
+	if instrument == audio.SfxInstrument && track.Sfx.Category == "" {
+		track.Sfx = audio.NewSfx(audio.SfxJump)
+	}
+
+	m.drum.Instrument = instrument
+	m.sfx.Instrument = instrument
+	m.sfx.Sfx = track.Sfx
+}
+
 // playNote plays a note at the given frequency using the current oscillator
 func (m *model) playNote(note audio.Note) {
-	// TODO: duration should be adjustable
-	duration := time.Millisecond * 250
-
-	synth := audio.NewSynth(
+	var instrument audio.Instrument = audio.NewSynth(
 		m.sampleRate,
 		m.oscillator1.Oscillator,
 		m.envelope1.Envelope,
 		m.oscillator2.Oscillator,
 		m.envelope2.Envelope,
-		m.mixer.Mixer)
-
-	synthStreamer := synth.Streamer(note, duration)
-	volumeAdjusted := &effects.Volume{
-		Streamer: synthStreamer,
-		Base:     2,
-		Volume:   volumeToDecibels(m.globalVolume),
-		Silent:   m.globalVolume == 0,
+		m.mixer.Mixer,
+		m.macros.Macros,
+		m.tracker.Tuning)
+	switch {
+	case m.drum.IsDrum():
+		instrument = audio.NewDrumSynth(m.sampleRate, m.drum.Drum, m.tracker.Tuning)
+	case m.sfx.IsSfx():
+		instrument = audio.NewSfxSynth(m.sampleRate, m.sfx.Sfx, m.tracker.Tuning)
 	}
 
-	// Clear previous sound and play the new note
-	speaker.Play(volumeAdjusted)
+	speaker.Lock()
+	// TODO: duration should be adjustable
+	m.engine.Play(m.tracker.CursorTrack, instrument, note, m.tracker.RowDuration())
+	speaker.Unlock()
 }
 
 // playRowNotes plays all notes in the specified row across all tracks
 func (m *model) playRowNotes(row int) {
-	if row < 0 || row >= m.tracker.NumRows {
+	speaker.Lock()
+	playRow(m.engine, m.sampleRate, m.tracker, row)
+	speaker.Unlock()
+}
+
+// setVolume changes the output volume of the live engine
+func (m *model) setVolume(volume float64) {
+	speaker.Lock()
+	m.engine.SetVolume(volume)
+	speaker.Unlock()
+}
+
+// setChannels applies the channel settings of all tracks to the live engine
+func (m *model) setChannels() {
+	speaker.Lock()
+	m.engine.SetChannels(trackChannels(m.tracker))
+	speaker.Unlock()
+}
+
+// setChannel stores the channel settings of a track, applies them to the live engine
+// and keeps the panels of the current track in sync
+func (m *model) setChannel(track int, channel audio.Channel) {
+	if track < 0 || track >= len(m.tracker.Tracks) {
 		return
 	}
 
-	// TODO: duration should be adjustable
-	duration := time.Millisecond * 250
-	var streamers []beep.Streamer
-
-	// Collect all note generators for this row
-	for trackIdx := 0; trackIdx < m.tracker.NumTracks; trackIdx++ {
-		track := m.tracker.Tracks[trackIdx]
-		trackRow := track.Rows[row]
-
-		// Skip empty notes
-		if audio.IsOff(trackRow.Note) {
-			continue
-		}
-
-		synth := audio.NewSynth(
-			m.sampleRate,
-			m.oscillator1.Oscillator,
-			m.envelope1.Envelope,
-			m.oscillator2.Oscillator,
-			m.envelope2.Envelope,
-			m.mixer.Mixer,
-		)
-
-		synthStreamer := synth.Streamer(trackRow.Note, duration)
-		streamers = append(streamers, synthStreamer)
+	m.tracker.Tracks[track].Channel = channel
+	if track == m.tracker.CursorTrack {
+		m.mixer.Channel = channel
+		m.syncEffects()
 	}
 
-	// If we have any notes to play, mix and play them
-	if len(streamers) > 0 {
-		mixed := beep.Mix(streamers...)
+	speaker.Lock()
+	m.engine.SetChannel(track, channel)
+	speaker.Unlock()
+}
 
-		// global vol
-		volumeAdjusted := &effects.Volume{
-			Streamer: mixed,
-			Base:     2,
-			Volume:   volumeToDecibels(m.globalVolume),
-			Silent:   m.globalVolume == 0,
-		}
+// setBuses applies the settings of all submix buses to the live engine
+func (m *model) setBuses() {
+	speaker.Lock()
+	m.engine.SetBuses(m.tracker.Buses)
+	speaker.Unlock()
+}
 
-		speaker.Play(volumeAdjusted)
+// setBus stores the settings of a submix bus and applies them to the live engine
+func (m *model) setBus(idx int, bus audio.Bus) {
+	if idx < 0 || idx >= len(m.tracker.Buses) {
+		return
 	}
+
+	m.tracker.Buses = slices.Clone(m.tracker.Buses)
+	m.tracker.Buses[idx] = bus
+	if m.effectsBus == idx+1 {
+		m.syncEffects()
+	}
+
+	speaker.Lock()
+	m.engine.SetBus(idx, bus)
+	speaker.Unlock()
+}
+
+// setMaster applies master bus settings to the live engine
+func (m *model) setMaster(master audio.Master) {
+	speaker.Lock()
+	m.engine.SetMaster(master)
+	speaker.Unlock()
 }
 
-func volumeToDecibels(volume float64) float64 {
-	if volume <= 0 {
-		return -999
+// setRowDuration applies the tempo of the song to the live engine
+func (m *model) setRowDuration() {
+	speaker.Lock()
+	m.engine.SetRowDuration(m.tracker.RowDuration())
+	speaker.Unlock()
+}
+
+// entering reports whether keys enter notes and values into the pattern,
+// which is always the case in the pattern editor unless vim is in normal mode
+func (m model) entering() bool {
+	return m.mode == TrackMode && (m.vim == nil || m.vim.Insert)
+}
+
+// keyContext returns the key bindings context of the current mode
+func (m model) keyContext() ui.KeyContext {
+	switch m.mode {
+	case TrackMode:
+		return ui.TrackContext
+	case Oscillator1EditMode, Oscillator2EditMode:
+		return ui.OscillatorContext
+	case Envelope1EditMode, Envelope2EditMode:
+		return ui.EnvelopeContext
+	case MixerEditMode:
+		return ui.MixerContext
+	case MasterEditMode:
+		return ui.MasterContext
+	case EffectsEditMode:
+		return ui.EffectsContext
+	case ConsoleMode:
+		return ui.ConsoleContext
+	case MacroEditMode:
+		return ui.MacrosContext
+	case DrumEditMode:
+		return ui.DrumContext
+	case SfxEditMode:
+		return ui.SfxContext
 	}
-	return math.Log2(volume) * 6
+	return ui.GlobalContext
+}
+
+// presetsOpen reports whether the envelope presets of the current mode are shown
+func (m model) presetsOpen() bool {
+	return (m.mode == Envelope1EditMode && m.envelope1.ShowModal) ||
+		(m.mode == Envelope2EditMode && m.envelope2.ShowModal)
 }
 
 // View renders the UI
@@ -487,6 +1246,9 @@ func (m model) View() string {
 	var header strings.Builder
 
 	modeStr := "TRACK"
+	if m.vim != nil {
+		modeStr = "TRACK " + m.vim.Mode()
+	}
 	switch m.mode {
 	case Envelope1EditMode:
 		modeStr = "ENVELOPE1"
@@ -494,6 +1256,18 @@ func (m model) View() string {
 		modeStr = "ENVELOPE2"
 	case MixerEditMode:
 		modeStr = "MIXER"
+	case MasterEditMode:
+		modeStr = "MASTER"
+	case EffectsEditMode:
+		modeStr = "EFFECTS"
+	case ConsoleMode:
+		modeStr = "MIXER CONSOLE"
+	case MacroEditMode:
+		modeStr = "MACROS"
+	case DrumEditMode:
+		modeStr = "DRUM"
+	case SfxEditMode:
+		modeStr = "SOUND FX"
 	case Oscillator1EditMode:
 		modeStr = "OSCILLATOR1"
 	case Oscillator2EditMode:
@@ -509,8 +1283,17 @@ func (m model) View() string {
 		}
 	}
 
-	header.WriteString(infoStyle.Render(fmt.Sprintf("Mode: %s | %s | Track: %d | Row: %d | Octave: %d",
-		modeStr, playStatus, m.tracker.CursorTrack, m.tracker.CursorRow, m.octave)))
+	if m.exporting != "" {
+		playStatus += " | EXPORTING " + m.exporting
+	}
+
+	header.WriteString(infoStyle.Render(fmt.Sprintf("Mode: %s | %s | BPM: %d | Track: %d | Row: %d | Octave: %d | Step: %d | Key: %s | Keyboard: %s",
+		modeStr, playStatus, m.tracker.BPM, m.tracker.CursorTrack, m.tracker.CursorRow, m.octave, m.tracker.EditStep, m.key, strings.ToUpper(string(m.keyboard)))))
+	if m.clipped {
+		header.WriteString(clipStyle.Render(" CLIP "))
+	} else if m.limiting {
+		header.WriteString(limitStyle.Render("LIMIT"))
+	}
 	header.WriteString("\n\n")
 
 	synthView := m.synthView()
@@ -523,10 +1306,46 @@ func (m model) View() string {
 	}
 
 	trackerViewWithBorder := trackerBorder.Render(trackerView)
+
+	// The mixer console takes the place of the pattern
+	if m.mode == ConsoleMode {
+		m.syncConsole()
+		trackerViewWithBorder = activePanelBorderStyle.Render(m.console.View())
+	}
+
+	// So does the macro editor
+	if m.mode == MacroEditMode {
+		trackerViewWithBorder = activePanelBorderStyle.Render(m.macros.View())
+	}
+
 	body := lipgloss.JoinVertical(lipgloss.Left, synthView, trackerViewWithBorder)
 
 	// Footer help
-	footer := helpStyle.Render("↑↓←→: Navigate | J: Jump | 1-7: Notes | Shift+1-6: Sharp Notes | +/-: Octave | [/]: Volume | W: Oscillator | E: Envelope | T: Track | p: Play/Pause | P: Loop | S: Save | L: Load | Q: Quit")
+	notesHelp := "1-7: Notes"
+	var shadowed func(key string) bool
+	switch {
+	case m.mode == TrackMode && !m.entering():
+		notesHelp = "hjkl: Move | w/b: Next/Previous Note | {/}: Beat | gg/G: Top/Bottom | dd/yy/p: Delete/Yank/Put Rows | m/': Marks | i: Insert"
+		shadowed = m.vim.Takes
+	case m.mode == TrackMode:
+		notesHelp = "↑↓←→: Navigate | Shift+↑↓←→: Select | 0-9/A-Z: Volume/Effect"
+		if m.keyboard != ui.DigitsLayout {
+			notesHelp += " | Z-M/Q-U: Notes"
+		} else {
+			notesHelp += " | 1-7: Notes"
+		}
+		if m.vim != nil {
+			notesHelp += " | Esc: Normal"
+		}
+		shadowed = func(key string) bool {
+			_, ok := m.keyboard.Semitone(key)
+			return ok || (m.vim != nil && m.vim.Takes(key))
+		}
+	}
+	footer := helpStyle.Render(notesHelp + " | " + m.keymap.Help(m.keyContext(), shadowed))
+	if m.commandLine.IsVisible() {
+		footer = m.commandLine.View()
+	}
 
 	// TODO: More generic modal handling, use commands?
 	if m.envelope1.ShowModal && m.mode == Envelope1EditMode {
@@ -561,6 +1380,10 @@ func (m model) synthView() string {
 	oscillator2Border := panelBorderStyle
 	envelope2Border := panelBorderStyle
 	mixerBorder := panelBorderStyle
+	masterBorder := panelBorderStyle
+	effectsBorder := panelBorderStyle
+	drumBorder := panelBorderStyle
+	sfxBorder := panelBorderStyle
 
 	switch m.mode {
 	case Oscillator1EditMode:
@@ -573,6 +1396,33 @@ func (m model) synthView() string {
 		envelope2Border = activePanelBorderStyle
 	case MixerEditMode:
 		mixerBorder = activePanelBorderStyle
+	case MasterEditMode:
+		masterBorder = activePanelBorderStyle
+	case EffectsEditMode:
+		effectsBorder = activePanelBorderStyle
+	case DrumEditMode:
+		drumBorder = activePanelBorderStyle
+	case SfxEditMode:
+		sfxBorder = activePanelBorderStyle
+	}
+
+	// Drum and sound effect tracks show their voice in place of the oscillators and envelopes,
+	// which stay reachable to edit the synth settings kept for the track
+	synthEditing := m.mode >= Oscillator1EditMode && m.mode <= Envelope2EditMode
+	var instrumentView string
+	switch {
+	case m.mode == DrumEditMode || (m.drum.IsDrum() && !synthEditing && m.mode != SfxEditMode):
+		instrumentView = drumBorder.Render(m.drum.View())
+	case m.mode == SfxEditMode || (m.sfx.IsSfx() && !synthEditing):
+		instrumentView = sfxBorder.Render(m.sfx.View())
+	}
+	if instrumentView != "" {
+		return lipgloss.JoinHorizontal(lipgloss.Top,
+			instrumentView,
+			mixerBorder.Render(m.mixer.View()),
+			effectsBorder.Render(m.effects.View()),
+			masterBorder.Render(m.master.View()),
+		)
 	}
 
 	return lipgloss.JoinHorizontal(lipgloss.Top,
@@ -581,6 +1431,8 @@ func (m model) synthView() string {
 		oscillator2Border.Render(oscillatorView2),
 		envelope2Border.Render(envelopeView2),
 		mixerBorder.Render(m.mixer.View()),
+		effectsBorder.Render(m.effects.View()),
+		masterBorder.Render(m.master.View()),
 	)
 }
 
@@ -592,6 +1444,24 @@ func main() {
 	tracker := ui.NewTracker(8, 64, 0, 0)
 	track := tracker.CurrentTrack()
 
+	engine := audio.NewEngine(sampleRate, tracker.RowDuration(), trackChannels(tracker), tracker.Buses, tracker.Master)
+	engine.SetVolume(1.0)
+
+	// Key bindings from the config directory replace the defaults
+	keymap := ui.DefaultKeymap()
+	if path, err := persistence.KeymapPath(); err == nil {
+		if keymap, err = persistence.LoadKeymap(path); err != nil {
+			fmt.Printf("Error in key bindings %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+
+	var vim *ui.Vim
+	if keymap.Profile == ui.VimProfile {
+		vim = ui.NewVim()
+	}
+
+	output := &terminalOutput{File: os.Stdout}
 	p := tea.NewProgram(
 		model{
 			sampleRate:   sampleRate,
@@ -599,15 +1469,29 @@ func main() {
 			envelope1:    ui.NewEnvelopeModel(selectedStyle, track.Envelope1),
 			oscillator2:  ui.NewOscillatorModel(selectedStyle, track.Oscillator2),
 			envelope2:    ui.NewEnvelopeModel(selectedStyle, track.Envelope2),
-			mixer:        ui.NewMixer(track.Mixer.Balance),
+			mixer:        ui.NewMixer(selectedStyle, track.Mixer, track.Channel),
+			master:       ui.NewMasterModel(selectedStyle, tracker.Master),
+			effects:      ui.NewEffectsModel(selectedStyle, track.Channel.Inserts),
+			console:      ui.NewConsoleModel(selectedStyle),
+			macros:       ui.NewMacroModel(selectedStyle, track.Macros),
+			drum:         ui.NewDrumModel(selectedStyle, track.Instrument, track.Drum),
+			sfx:          ui.NewSfxModel(selectedStyle, track.Instrument, track.Sfx),
 			tracker:      tracker,
+			engine:       engine,
+			history:      ui.NewHistory(),
 			mode:         TrackMode,
 			octave:       4,
+			keymap:       keymap,
+			vim:          vim,
+			keyboard:     keymap.Keyboard,
 			globalVolume: 1.0,
 			fileDialog:   ui.NewFileDialog(modalBorderStyle),
+			commandLine:  ui.NewCommandLine(commandNames(keymap)),
+			output:       output,
 		},
 
 		tea.WithAltScreen(),
+		tea.WithOutput(output),
 	)
 
 	if _, err := p.Run(); err != nil {
@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/goccy/go-yaml"
+	"github.com/tetrackt/tetrackt/ui"
+)
+
+// SavedKeymap is the YAML form of the key bindings file, the keys of actions per context
+// replace the default ones, e.g.
+//
+//	profile: vim
+//	keyboard: qwertz
+//	global:
+//	  save: [ctrl+s]
+//	track:
+//	  play: [space, enter]
+//	envelope:
+//	  presets: [p]
+type SavedKeymap struct {
+	Profile    ui.KeymapProfile    `yaml:"profile"`
+	Keyboard   ui.KeyboardLayout   `yaml:"keyboard"`
+	Global     map[string][]string `yaml:"global"`
+	Track      map[string][]string `yaml:"track"`
+	Oscillator map[string][]string `yaml:"oscillator"`
+	Envelope   map[string][]string `yaml:"envelope"`
+	Mixer      map[string][]string `yaml:"mixer"`
+	Master     map[string][]string `yaml:"master"`
+	Effects    map[string][]string `yaml:"effects"`
+	Console    map[string][]string `yaml:"console"`
+	Macros     map[string][]string `yaml:"macros"`
+	Drum       map[string][]string `yaml:"drum"`
+	Sfx        map[string][]string `yaml:"sfx"`
+}
+
+// KeymapPath returns the path of the key bindings file in the user config directory
+func KeymapPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tetrackt", "keys.yaml"), nil
+}
+
+// LoadKeymap loads the key bindings file on top of the default keymap.
+// A missing file leaves the defaults, unknown actions and conflicting keys are errors.
+func LoadKeymap(filename string) (*ui.Keymap, error) {
+	keymap := ui.DefaultKeymap()
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return keymap, nil
+	}
+	if err != nil {
+		return keymap, err
+	}
+
+	var saved SavedKeymap
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		return keymap, err
+	}
+
+	if saved.Profile != "" {
+		if err := keymap.UseProfile(saved.Profile); err != nil {
+			return keymap, err
+		}
+	}
+
+	if saved.Keyboard != "" {
+		if !slices.Contains(ui.KeyboardLayouts, saved.Keyboard) {
+			return keymap, fmt.Errorf("unknown keyboard layout %q", saved.Keyboard)
+		}
+		keymap.Keyboard = saved.Keyboard
+	}
+
+	contexts := map[ui.KeyContext]map[string][]string{
+		ui.GlobalContext:     saved.Global,
+		ui.TrackContext:      saved.Track,
+		ui.OscillatorContext: saved.Oscillator,
+		ui.EnvelopeContext:   saved.Envelope,
+		ui.MixerContext:      saved.Mixer,
+		ui.MasterContext:     saved.Master,
+		ui.EffectsContext:    saved.Effects,
+		ui.ConsoleContext:    saved.Console,
+		ui.MacrosContext:     saved.Macros,
+		ui.DrumContext:       saved.Drum,
+		ui.SfxContext:        saved.Sfx,
+	}
+	for context, bindings := range contexts {
+		for action, keys := range bindings {
+			if err := keymap.Bind(context, ui.Action(action), keys); err != nil {
+				return keymap, err
+			}
+		}
+	}
+
+	return keymap, keymap.Validate()
+}
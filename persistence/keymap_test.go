@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tetrackt/tetrackt/ui"
+)
+
+func writeKeymap(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write keymap: %v", err)
+	}
+	return filename
+}
+
+func TestLoadKeymap(t *testing.T) {
+	keymap, err := LoadKeymap(writeKeymap(t, "keyboard: qwertz\nglobal:\n  save: [ctrl+s]\ntrack:\n  play: [space, enter]\nenvelope:\n  presets: [p]\n"))
+	if err != nil {
+		t.Fatalf("Failed to load keymap: %v", err)
+	}
+
+	if keymap.Keyboard != ui.QwertzLayout {
+		t.Errorf("Expected qwertz keyboard, got %s", keymap.Keyboard)
+	}
+	if action, _ := keymap.Action(ui.GlobalContext, "ctrl+s"); action != ui.ActionSave {
+		t.Errorf("Expected ctrl+s to save, got %q", action)
+	}
+	if _, ok := keymap.Action(ui.GlobalContext, "s"); ok {
+		t.Error("Expected s to be unbound")
+	}
+	if action, _ := keymap.Action(ui.TrackContext, " "); action != ui.ActionPlay {
+		t.Errorf("Expected space to play in the pattern editor, got %q", action)
+	}
+	if action, _ := keymap.Action(ui.EnvelopeContext, "p"); action != ui.ActionPresets {
+		t.Errorf("Expected p to open the envelope presets, got %q", action)
+	}
+
+	// A missing file keeps the defaults
+	keymap, err = LoadKeymap(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Expected defaults for a missing file, got %v", err)
+	}
+	if action, _ := keymap.Action(ui.TrackContext, "s"); action != ui.ActionSave {
+		t.Errorf("Expected default save key, got %q", action)
+	}
+}
+
+func TestLoadKeymapErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown action":   "global:\n  dance: [x]\n",
+		"unknown keyboard": "keyboard: dvorak\n",
+		"conflict":         "global:\n  save: [l]\n",
+		"piano key":        "track:\n  clear: [z]\n",
+		"no pattern key":   "global:\n  load: [l]\n",
+	}
+
+	for name, content := range tests {
+		if _, err := LoadKeymap(writeKeymap(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if name == "conflict" && !strings.Contains(err.Error(), "load") {
+			t.Errorf("Expected the conflicting action in the error, got %v", err)
+		}
+	}
+}
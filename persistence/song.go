@@ -18,28 +18,46 @@ type SavedTrackRow struct {
 
 // SavedTrack is the YAML-serializable form of Track
 type SavedTrack struct {
-	Oscillator1      string          `yaml:"oscillator1"`
-	Oscillator1Phase float64         `yaml:"oscillator1_phase"`
-	Envelope1        audio.Envelope  `yaml:"envelope1"`
-	Oscillator2      string          `yaml:"oscillator2"`
-	Oscillator2Phase float64         `yaml:"oscillator2_phase"`
-	Envelope2        audio.Envelope  `yaml:"envelope2"`
-	Mixer            float64         `yaml:"mixer"`
-	Rows             []SavedTrackRow `yaml:"rows"`
+	Oscillator1      string               `yaml:"oscillator1"`
+	Oscillator1Phase float64              `yaml:"oscillator1_phase"`
+	Envelope1        audio.Envelope       `yaml:"envelope1"`
+	Oscillator2      string               `yaml:"oscillator2"`
+	Oscillator2Phase float64              `yaml:"oscillator2_phase"`
+	Envelope2        audio.Envelope       `yaml:"envelope2"`
+	Mixer            float64              `yaml:"mixer"`
+	MixerEqualPower  bool                 `yaml:"mixer_equal_power"`
+	Oscillator1Level float64              `yaml:"oscillator1_level"`
+	Oscillator1Pan   float64              `yaml:"oscillator1_pan"`
+	Oscillator2Level float64              `yaml:"oscillator2_level"`
+	Oscillator2Pan   float64              `yaml:"oscillator2_pan"`
+	Macros           audio.Macros         `yaml:"macros"`
+	Instrument       audio.InstrumentType `yaml:"instrument"`
+	Drum             audio.Drum           `yaml:"drum"`
+	Sfx              audio.Sfx            `yaml:"sfx"`
+	Channel          audio.Channel        `yaml:"channel"`
+	Rows             []SavedTrackRow      `yaml:"rows"`
 }
 
 // SavedSong is the complete song structure for YAML serialization
 type SavedSong struct {
+	BPM       int          `yaml:"bpm"`
 	NumRows   int          `yaml:"num_rows"`
 	NumTracks int          `yaml:"num_tracks"`
+	Master    audio.Master `yaml:"master"`
+	Buses     []audio.Bus  `yaml:"buses"`
+	Tuning    audio.Tuning `yaml:"tuning"`
 	Tracks    []SavedTrack `yaml:"tracks"`
 }
 
 // TracksToSong converts the runtime TrackerModel to a SavedSong for YAML serialization
 func TracksToSong(tracker *ui.TrackerModel) *SavedSong {
 	saved := &SavedSong{
+		BPM:       tracker.BPM,
 		NumRows:   tracker.NumRows,
 		NumTracks: tracker.NumTracks,
+		Master:    tracker.Master,
+		Buses:     tracker.Buses,
+		Tuning:    tracker.Tuning,
 		Tracks:    make([]SavedTrack, tracker.NumTracks),
 	}
 
@@ -47,8 +65,8 @@ func TracksToSong(tracker *ui.TrackerModel) *SavedSong {
 		rows := make([]SavedTrackRow, len(track.Rows))
 		for j, row := range track.Rows {
 			rows[j] = SavedTrackRow{
-				Base:   string(row.Note.Base),
-				Octave: int(row.Note.Octave),
+				Base:   string(row.Note.Base()),
+				Octave: int(row.Note.Octave()),
 				Volume: row.Volume,
 				Effect: row.Effect,
 			}
@@ -61,6 +79,16 @@ func TracksToSong(tracker *ui.TrackerModel) *SavedSong {
 			Oscillator2Phase: track.Oscillator2.Phase,
 			Envelope2:        track.Envelope2,
 			Mixer:            track.Mixer.Balance,
+			MixerEqualPower:  track.Mixer.EqualPower,
+			Oscillator1Level: track.Mixer.Level1,
+			Oscillator1Pan:   track.Mixer.Pan1,
+			Oscillator2Level: track.Mixer.Level2,
+			Oscillator2Pan:   track.Mixer.Pan2,
+			Macros:           track.Macros,
+			Instrument:       track.Instrument,
+			Drum:             track.Drum,
+			Sfx:              track.Sfx,
+			Channel:          track.Channel,
 			Rows:             rows,
 		}
 	}
@@ -71,8 +99,16 @@ func TracksToSong(tracker *ui.TrackerModel) *SavedSong {
 // This fixes the TODO: instead of creating a new model, it updates the existing one
 func SongToTracks(saved *SavedSong, tracker *ui.TrackerModel) {
 	// Update tracker dimensions
+	tracker.BPM = saved.BPM
+	if tracker.BPM == 0 {
+		// Songs saved before the tempo setting play at the tempo they were made with
+		tracker.BPM = ui.DefaultBPM
+	}
 	tracker.NumRows = saved.NumRows
 	tracker.NumTracks = saved.NumTracks
+	tracker.Master = saved.Master
+	tracker.Buses = saved.Buses
+	tracker.Tuning = saved.Tuning
 
 	// Resize tracks slice if needed
 	if len(tracker.Tracks) != saved.NumTracks {
@@ -86,7 +122,19 @@ func SongToTracks(saved *SavedSong, tracker *ui.TrackerModel) {
 		track.Envelope1 = savedTrack.Envelope1
 		track.Oscillator2 = audio.Oscillator{Type: audio.OscillatorType(savedTrack.Oscillator2), Phase: savedTrack.Oscillator2Phase}
 		track.Envelope2 = savedTrack.Envelope2
-		track.Mixer = audio.Mixer{Balance: savedTrack.Mixer}
+		track.Mixer = audio.Mixer{
+			Balance:    savedTrack.Mixer,
+			EqualPower: savedTrack.MixerEqualPower,
+			Level1:     savedTrack.Oscillator1Level,
+			Level2:     savedTrack.Oscillator2Level,
+			Pan1:       savedTrack.Oscillator1Pan,
+			Pan2:       savedTrack.Oscillator2Pan,
+		}
+		track.Macros = savedTrack.Macros
+		track.Instrument = savedTrack.Instrument
+		track.Drum = savedTrack.Drum
+		track.Sfx = savedTrack.Sfx
+		track.Channel = savedTrack.Channel
 
 		// Resize rows slice if needed
 		if len(track.Rows) != saved.NumRows {
@@ -97,7 +145,7 @@ func SongToTracks(saved *SavedSong, tracker *ui.TrackerModel) {
 		for j, row := range savedTrack.Rows {
 			if j < len(track.Rows) {
 				track.Rows[j] = ui.TrackRow{
-					Note:   audio.Note{Base: audio.Base(row.Base), Octave: audio.Octave(row.Octave)},
+					Note:   audio.NewNote(audio.Base(row.Base), audio.Octave(row.Octave)),
 					Volume: row.Volume,
 					Effect: row.Effect,
 				}
@@ -105,7 +153,8 @@ func SongToTracks(saved *SavedSong, tracker *ui.TrackerModel) {
 		}
 	}
 
-	// Reset cursor to safe position
+	// Reset cursor to safe position, a selection may span rows and tracks the song does not have
+	tracker.ClearSelection()
 	if tracker.CursorTrack >= tracker.NumTracks {
 		tracker.CursorTrack = 0
 	}
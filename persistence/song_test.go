@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/tetrackt/tetrackt/audio"
@@ -15,13 +16,44 @@ func TestSaveAndLoad(t *testing.T) {
 	// Add some test data
 	tracker.Tracks[0].Oscillator1 = audio.Oscillator{Type: audio.Sine}
 	tracker.Tracks[0].Oscillator2 = audio.Oscillator{Type: audio.Square}
-	tracker.Tracks[0].Mixer = audio.Mixer{Balance: 0.75}
+	tracker.Tracks[0].Mixer = audio.Mixer{Balance: 0.75, EqualPower: true, Level1: -3, Level2: -12, Pan1: -0.5, Pan2: 0.25}
+	tracker.Tracks[0].Macros = audio.Macros{
+		Volume:   audio.Macro{Values: []int{15, 12, 8, 8, 4}, Loop: 2, Release: 3},
+		Arpeggio: audio.Macro{Values: []int{0, 4, 7}, Loop: 0, Release: -1},
+		Pitch:    audio.Macro{Values: []int{-20, 0, 20, 0}, Loop: 0, Release: -1},
+		Duty:     audio.Macro{Values: []int{2, 1}, Loop: -1, Release: -1},
+	}
+	tracker.Tracks[1].Instrument = audio.DrumInstrument
+	tracker.Tracks[1].Drum = audio.NewDrum(audio.DrumClap)
+	tracker.Tracks[2].Instrument = audio.SfxInstrument
+	tracker.Tracks[2].Sfx = audio.Sfx{Category: audio.SfxCoin, Wave: audio.Square, Frequency: 988, Jump: 7, JumpTime: 60, Sustain: 80, Decay: 250, Punch: 0.4}
 	tracker.Tracks[0].Envelope1 = audio.Envelope{
 		Attack:  0.1,
 		Decay:   0.2,
 		Sustain: 0.5,
 		Release: 0.3,
 	}
+	tracker.BPM = 140
+	tracker.Master.Delay = audio.Delay{Time: 180, Rows: 3, Feedback: 0.4, Mix: 0.25, PingPong: true}
+	tracker.Master.Bitcrusher = audio.Bitcrusher{Bits: 6, Downsample: 2}
+	tracker.Master.Reverb = audio.Reverb{Size: 0.8, Damping: 0.3, Mix: 0.4}
+	tracker.Master.Limiter = audio.Limiter{Headroom: -9, Ceiling: -1.5, SoftClip: true}
+	tracker.Buses = []audio.Bus{
+		{Name: "Drums", Gain: -3, Mute: true, Inserts: []audio.Insert{audio.NewInsert(audio.Chorus)}},
+		{Name: "Bass", Gain: 2.5, Ducker: audio.NewDucker(1), Inserts: []audio.Insert{audio.NewInsert(audio.Overdrive)}},
+	}
+	tracker.Tracks[0].Channel = audio.Channel{
+		Bus:        1,
+		Voicing:    audio.Voicing{Polyphony: 3, Steal: audio.StealQuietest, Mode: audio.Legato},
+		Ducker:     audio.Ducker{Key: 2, Threshold: -30, Amount: 9, Attack: 3, Release: 220},
+		Bitcrusher: audio.Bitcrusher{Bits: 4, Downsample: 8},
+		ReverbSend: 0.6,
+		Gain:       -4.5,
+		Pan:        -0.3,
+		Mute:       true,
+		Solo:       true,
+		Inserts:    []audio.Insert{audio.NewInsert(audio.Overdrive), audio.NewInsert(audio.Flanger)},
+	}
 	tracker.Tracks[0].Rows[0] = ui.TrackRow{
 		Note:   audio.NewNote("C", 4),
 		Volume: 64,
@@ -32,6 +64,10 @@ func TestSaveAndLoad(t *testing.T) {
 		Volume: 80,
 		Effect: "---",
 	}
+	tracker.Tracks[0].Rows[2] = ui.TrackRow{
+		Note:   audio.NewNote("G", 9),
+		Effect: "---",
+	}
 
 	// Save to a temporary file
 	tmpFile := "test_song.yaml"
@@ -60,6 +96,19 @@ func TestSaveAndLoad(t *testing.T) {
 	if newTracker.NumTracks != 4 {
 		t.Errorf("Expected NumTracks=4, got %d", newTracker.NumTracks)
 	}
+	if newTracker.BPM != 140 {
+		t.Errorf("Expected BPM=140, got %d", newTracker.BPM)
+	}
+
+	// Verify master bus data
+	if newTracker.Master != tracker.Master {
+		t.Errorf("Expected Master=%+v, got %+v", tracker.Master, newTracker.Master)
+	}
+
+	// Verify submix buses
+	if !reflect.DeepEqual(newTracker.Buses, tracker.Buses) {
+		t.Errorf("Expected Buses=%+v, got %+v", tracker.Buses, newTracker.Buses)
+	}
 
 	// Verify track data
 	if newTracker.Tracks[0].Oscillator1 != (audio.Oscillator{Type: audio.Sine}) {
@@ -68,8 +117,23 @@ func TestSaveAndLoad(t *testing.T) {
 	if newTracker.Tracks[0].Oscillator2 != (audio.Oscillator{Type: audio.Square}) {
 		t.Errorf("Expected Oscillator2=Square, got %v", newTracker.Tracks[0].Oscillator2)
 	}
-	if newTracker.Tracks[0].Mixer != (audio.Mixer{Balance: 0.75}) {
-		t.Errorf("Expected Mixer=0.75, got %f", newTracker.Tracks[0].Mixer.Balance)
+	if !reflect.DeepEqual(newTracker.Tracks[0].Channel, tracker.Tracks[0].Channel) {
+		t.Errorf("Expected Channel=%+v, got %+v", tracker.Tracks[0].Channel, newTracker.Tracks[0].Channel)
+	}
+	if newTracker.Tracks[0].Mixer != tracker.Tracks[0].Mixer {
+		t.Errorf("Expected Mixer=%+v, got %+v", tracker.Tracks[0].Mixer, newTracker.Tracks[0].Mixer)
+	}
+	if !reflect.DeepEqual(newTracker.Tracks[0].Macros, tracker.Tracks[0].Macros) {
+		t.Errorf("Expected Macros=%+v, got %+v", tracker.Tracks[0].Macros, newTracker.Tracks[0].Macros)
+	}
+	if newTracker.Tracks[1].Instrument != audio.DrumInstrument {
+		t.Errorf("Expected Instrument=drum, got %q", newTracker.Tracks[1].Instrument)
+	}
+	if newTracker.Tracks[1].Drum != tracker.Tracks[1].Drum {
+		t.Errorf("Expected Drum=%+v, got %+v", tracker.Tracks[1].Drum, newTracker.Tracks[1].Drum)
+	}
+	if newTracker.Tracks[2].Instrument != audio.SfxInstrument || newTracker.Tracks[2].Sfx != tracker.Tracks[2].Sfx {
+		t.Errorf("Expected sfx instrument %+v, got %q %+v", tracker.Tracks[2].Sfx, newTracker.Tracks[2].Instrument, newTracker.Tracks[2].Sfx)
 	}
 
 	// Verify row data
@@ -85,6 +149,12 @@ func TestSaveAndLoad(t *testing.T) {
 	if newTracker.Tracks[0].Rows[1].Volume != 80 {
 		t.Errorf("Expected Volume=80, got %d", newTracker.Tracks[0].Rows[1].Volume)
 	}
+	if newTracker.Tracks[0].Rows[2].Note.MIDI() != 127 {
+		t.Errorf("Expected Note=G-9, got %s", newTracker.Tracks[0].Rows[2].Note.String())
+	}
+	if !audio.IsOff(newTracker.Tracks[0].Rows[3].Note) {
+		t.Errorf("Expected Note=---, got %s", newTracker.Tracks[0].Rows[3].Note.String())
+	}
 
 	// Verify envelope data
 	if newTracker.Tracks[0].Envelope1.Attack != 0.1 {
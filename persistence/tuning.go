@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// LoadTuningFile applies a Scala scale (.scl) or keyboard mapping (.kbm) file to the tuning.
+// A keyboard mapping without a loaded scale maps a 12-tone equal tempered scale, a scale
+// keeps the keyboard mapping loaded before so the reference frequency stays on its note.
+func LoadTuningFile(filename string, tuning audio.Tuning) (audio.Tuning, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return tuning, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(filename), ".kbm") {
+		mapping, frequency, err := audio.ParseKeyboardMapping(file)
+		if err != nil {
+			return tuning, err
+		}
+
+		scale := &audio.Scale{Description: "12-TET", Cents: []float64{100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200}}
+		if tuning.Scale != nil {
+			copied := *tuning.Scale
+			scale = &copied
+		}
+		scale.Mapping = mapping
+
+		tuning.Scale = scale
+		tuning.Reference = frequency
+		tuning.Temperament = audio.ScalaTemperament
+		return tuning, nil
+	}
+
+	scale, err := audio.ParseScale(file)
+	if err != nil {
+		return tuning, err
+	}
+
+	if tuning.Scale != nil {
+		scale.Mapping = tuning.Scale.Mapping
+	}
+
+	tuning.Scale = scale
+	tuning.Temperament = audio.ScalaTemperament
+	return tuning, nil
+}
@@ -0,0 +1,75 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tetrackt/tetrackt/audio"
+	"github.com/tetrackt/tetrackt/ui"
+)
+
+func TestLoadTuningFile(t *testing.T) {
+	dir := t.TempDir()
+
+	scl := filepath.Join(dir, "pentatonic.scl")
+	err := os.WriteFile(scl, []byte("! pentatonic.scl\n!\nJust pentatonic\n 5\n!\n9/8\n5/4\n3/2\n5/3\n2/1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kbm := filepath.Join(dir, "pentatonic.kbm")
+	err = os.WriteFile(kbm, []byte("! pentatonic.kbm\n5\n0\n127\n60\n60\n261.0\n5\n0\n1\n2\n3\n4\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuning, err := LoadTuningFile(scl, audio.Tuning{})
+	if err != nil {
+		t.Fatalf("LoadTuningFile(.scl) failed: %v", err)
+	}
+	if tuning.Temperament != audio.ScalaTemperament || tuning.Scale == nil {
+		t.Errorf("Expected the scale to be used, got %+v", tuning)
+	}
+
+	// A keyboard mapping keeps the loaded scale and sets the reference pitch
+	tuning, err = LoadTuningFile(kbm, tuning)
+	if err != nil {
+		t.Fatalf("LoadTuningFile(.kbm) failed: %v", err)
+	}
+	if tuning.Reference != 261 || len(tuning.Scale.Cents) != 5 || tuning.Scale.Mapping.ReferenceNote != 60 {
+		t.Errorf("Unexpected tuning %+v, scale %+v", tuning, tuning.Scale)
+	}
+
+	// A scale loaded after a keyboard mapping keeps it, so the reference stays on C-4
+	tuning, err = LoadTuningFile(kbm, audio.Tuning{})
+	if err != nil {
+		t.Fatalf("LoadTuningFile(.kbm) failed: %v", err)
+	}
+	tuning, err = LoadTuningFile(scl, tuning)
+	if err != nil {
+		t.Fatalf("LoadTuningFile(.scl) failed: %v", err)
+	}
+	if tuning.Scale.Mapping.ReferenceNote != 60 || len(tuning.Scale.Cents) != 5 {
+		t.Errorf("Expected the mapping to be kept with the new scale, got %+v", tuning.Scale)
+	}
+	if got := tuning.Frequency(audio.NewNote(audio.BaseC, audio.Octave4)); got != 261 {
+		t.Errorf("Expected C-4 at the 261Hz reference, got %f", got)
+	}
+
+	// The tuning is stored with the song
+	tracker := ui.NewTracker(1, 4, 0, 0)
+	tracker.Tuning = tuning
+
+	file := filepath.Join(dir, "song.yaml")
+	if err := SaveToFile(file, TracksToSong(tracker)); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Tuning.Frequency(audio.NewNote("D", 4)); got != tuning.Frequency(audio.NewNote("D", 4)) {
+		t.Errorf("Expected saved tuning to match, got D-4=%f", got)
+	}
+}
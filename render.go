@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/wav"
+	"github.com/tetrackt/tetrackt/audio"
+	"github.com/tetrackt/tetrackt/ui"
+)
+
+// renderTail is rendered after the last row so effects like the delay can ring out
+const renderTail = 2 * time.Second
+
+// playRow plays all notes in the specified row on the channels of their tracks
+func playRow(engine *audio.Engine, sampleRate beep.SampleRate, tracker *ui.TrackerModel, row int) {
+	if row < 0 || row >= tracker.NumRows {
+		return
+	}
+
+	for trackIdx := 0; trackIdx < tracker.NumTracks; trackIdx++ {
+		track := tracker.Tracks[trackIdx]
+		trackRow := track.Rows[row]
+
+		// Skip empty notes
+		if audio.IsOff(trackRow.Note) {
+			continue
+		}
+
+		// TODO: duration should be adjustable
+		engine.Play(trackIdx, trackInstrument(sampleRate, track, tracker.Tuning), trackRow.Note, tracker.RowDuration())
+	}
+}
+
+// trackInstrument creates the instrument playing the notes of a track
+func trackInstrument(sampleRate beep.SampleRate, track ui.Track, tuning audio.Tuning) audio.Instrument {
+	switch track.Instrument {
+	case audio.DrumInstrument:
+		return audio.NewDrumSynth(sampleRate, track.Drum, tuning)
+	case audio.SfxInstrument:
+		return audio.NewSfxSynth(sampleRate, track.Sfx, tuning)
+	}
+
+	return audio.NewSynth(
+		sampleRate,
+		track.Oscillator1,
+		track.Envelope1,
+		track.Oscillator2,
+		track.Envelope2,
+		track.Mixer,
+		track.Macros,
+		tuning,
+	)
+}
+
+// trackChannels collects the channel settings of all tracks
+func trackChannels(tracker *ui.TrackerModel) []audio.Channel {
+	channels := make([]audio.Channel, len(tracker.Tracks))
+	for i, track := range tracker.Tracks {
+		channels[i] = track.Channel
+	}
+	return channels
+}
+
+// songRenderer implements beep.Streamer by sequencing all rows of the song through its own engine
+type songRenderer struct {
+	sampleRate beep.SampleRate
+	tracker    *ui.TrackerModel
+	engine     *audio.Engine
+	row        int
+	remaining  int // samples left in the current row or tail
+}
+
+// renderSong returns a streamer that renders the song once, followed by the effect tail.
+// It sequences a copy of the pattern, so it can render in the background while editing.
+func (m *model) renderSong() beep.Streamer {
+	engine := audio.NewEngine(m.sampleRate, m.tracker.RowDuration(), trackChannels(m.tracker), m.tracker.Buses, m.tracker.Master)
+	engine.SetVolume(m.globalVolume)
+
+	// Settings are replaced on edit, only the rows are changed in place
+	tracker := *m.tracker
+	tracker.Tracks = slices.Clone(tracker.Tracks)
+	for i := range tracker.Tracks {
+		tracker.Tracks[i].Rows = slices.Clone(tracker.Tracks[i].Rows)
+	}
+
+	return &songRenderer{
+		sampleRate: m.sampleRate,
+		tracker:    &tracker,
+		engine:     engine,
+		row:        -1,
+	}
+}
+
+// Stream fills the samples buffer with the rendered song
+func (r *songRenderer) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		if r.remaining == 0 {
+			if r.row >= r.tracker.NumRows {
+				break
+			}
+
+			r.row++
+			if r.row < r.tracker.NumRows {
+				playRow(r.engine, r.sampleRate, r.tracker, r.row)
+				r.remaining = r.sampleRate.N(r.tracker.RowDuration())
+			} else {
+				r.remaining = r.sampleRate.N(renderTail)
+			}
+		}
+
+		count := min(r.remaining, len(samples)-n)
+		r.engine.Stream(samples[n : n+count])
+		r.remaining -= count
+		n += count
+	}
+
+	return n, n > 0
+}
+
+// Err returns any error that occurred during rendering
+func (r *songRenderer) Err() error {
+	return r.engine.Err()
+}
+
+// export hides the file dialog and returns a command writing the streamer to a WAV file
+// in the background, reporting the result with an exportedMsg
+func (m *model) export(filename string, streamer beep.Streamer) tea.Cmd {
+	mode := m.fileDialog.Mode
+	sampleRate := m.sampleRate
+	m.exporting = filename
+	m.fileDialog.Hide()
+
+	return func() tea.Msg {
+		return exportedMsg{mode: mode, filename: filename, err: exportWAV(filename, streamer, sampleRate)}
+	}
+}
+
+// exportWAV writes the streamer to a 16-bit stereo WAV file
+func exportWAV(filename string, streamer beep.Streamer, sampleRate beep.SampleRate) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	format := beep.Format{SampleRate: sampleRate, NumChannels: 2, Precision: 2}
+	return wav.Encode(f, streamer, format)
+}
@@ -0,0 +1,288 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// movementKeys are the cursor keys of the pattern editor, with shift they extend the selection
+var movementKeys = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true, "home": true, "end": true,
+}
+
+// PasteMode selects how pasted rows combine with the rows at the cursor
+type PasteMode int
+
+const (
+	PasteInsert    PasteMode = iota // shift the rows below down, rows pushed past the end are dropped
+	PasteOverwrite                  // replace the rows
+	PasteMix                        // only fill empty notes, volumes and effects
+)
+
+// Clipboard holds copied rows, one column of rows per track
+type Clipboard struct {
+	Tracks [][]TrackRow
+}
+
+// Empty reports whether nothing has been copied
+func (c Clipboard) Empty() bool {
+	return len(c.Tracks) == 0
+}
+
+// clipboardHeader starts the text format of copied rows, like the "ModPlug Tracker" line of OpenMPT
+const clipboardHeader = "tetrackt pattern"
+
+// Text formats the clipboard as plain text, a header line followed by one line per row
+// with a "|note volume effect" cell per track, e.g. "|C-4 64 A01|--- .. ---"
+func (c Clipboard) Text() string {
+	var text strings.Builder
+	text.WriteString(clipboardHeader + "\n")
+
+	if c.Empty() {
+		return text.String()
+	}
+
+	for row := range c.Tracks[0] {
+		for _, column := range c.Tracks {
+			text.WriteString("|" + formatCell(column[row]))
+		}
+		text.WriteString("\n")
+	}
+
+	return text.String()
+}
+
+// ParseClipboard parses rows in the text format of Clipboard.Text. The header is optional
+// and lines not starting with a cell are skipped, so snippets survive being quoted in chats.
+// Rows with fewer cells than the widest row are filled with empty cells.
+func ParseClipboard(text string) (Clipboard, error) {
+	var rows [][]TrackRow
+	tracks := 0
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+
+		var row []TrackRow
+		for _, cell := range strings.Split(line[1:], "|") {
+			trackRow, err := parseCell(cell)
+			if err != nil {
+				return Clipboard{}, fmt.Errorf("row %d: %w", len(rows)+1, err)
+			}
+			row = append(row, trackRow)
+		}
+
+		rows = append(rows, row)
+		tracks = max(tracks, len(row))
+	}
+
+	if len(rows) == 0 {
+		return Clipboard{}, errors.New("no pattern rows found")
+	}
+
+	clipboard := Clipboard{Tracks: make([][]TrackRow, tracks)}
+	for track := range clipboard.Tracks {
+		column := make([]TrackRow, len(rows))
+		for i, row := range rows {
+			column[i] = emptyRow()
+			if track < len(row) {
+				column[i] = row[track]
+			}
+		}
+		clipboard.Tracks[track] = column
+	}
+
+	return clipboard, nil
+}
+
+// parseCell parses a "note volume effect" cell, missing columns are empty
+func parseCell(cell string) (TrackRow, error) {
+	row := emptyRow()
+	fields := strings.Fields(cell)
+
+	if len(fields) > 0 {
+		note, err := audio.ParseNote(fields[0])
+		if err != nil {
+			return row, err
+		}
+		row.Note = note
+	}
+
+	if len(fields) > 1 && fields[1] != ".." {
+		volume, err := strconv.Atoi(fields[1])
+		if err != nil || volume < 0 || volume > maxRowVolume {
+			return row, fmt.Errorf("invalid volume %q", fields[1])
+		}
+		row.Volume = volume
+	}
+
+	if len(fields) > 2 && fields[2] != "---" {
+		effect := strings.ToUpper(fields[2])
+		if !isEffect(effect) {
+			return row, fmt.Errorf("invalid effect %q", fields[2])
+		}
+		row.Effect = effect
+	}
+
+	return row, nil
+}
+
+// isEffect reports whether effect is a command digit or letter followed by two hex digits,
+// as typed by EnterValue
+func isEffect(effect string) bool {
+	runes := []rune(effect)
+	return len(runes) == 3 && isEffectCommand(runes[0]) && isHexDigit(runes[1]) && isHexDigit(runes[2])
+}
+
+// Block is a rectangular range of rows across tracks
+type Block struct {
+	Track, Row   int // top left cell
+	Tracks, Rows int
+}
+
+// Contains reports whether the cell of the track and row lies in the block
+func (b Block) Contains(track, row int) bool {
+	return track >= b.Track && track < b.Track+b.Tracks && row >= b.Row && row < b.Row+b.Rows
+}
+
+// emptyRow returns a row without note, volume and effect
+func emptyRow() TrackRow {
+	return TrackRow{Note: audio.Off(), Volume: 0, Effect: "---"}
+}
+
+// isEmptyEffect reports whether an effect column holds no command
+func isEmptyEffect(effect string) bool {
+	return effect == "" || effect == "---"
+}
+
+// startSelection anchors a new selection at the cursor unless one is in progress
+func (m *TrackerModel) startSelection() {
+	if m.selecting {
+		return
+	}
+	m.selecting = true
+	m.anchorTrack, m.anchorRow = m.CursorTrack, m.CursorRow
+}
+
+// ClearSelection drops the block selection
+func (m *TrackerModel) ClearSelection() {
+	m.selecting = false
+}
+
+// HasSelection reports whether a block is selected
+func (m *TrackerModel) HasSelection() bool {
+	return m.selecting
+}
+
+// Selection returns the selected block, the cursor cell without a selection
+func (m *TrackerModel) Selection() Block {
+	if !m.selecting {
+		return Block{Track: m.CursorTrack, Row: m.CursorRow, Tracks: 1, Rows: 1}
+	}
+
+	track, row := min(m.anchorTrack, m.CursorTrack), min(m.anchorRow, m.CursorRow)
+	return Block{
+		Track:  track,
+		Row:    row,
+		Tracks: max(m.anchorTrack, m.CursorTrack) - track + 1,
+		Rows:   max(m.anchorRow, m.CursorRow) - row + 1,
+	}
+}
+
+// block returns copies of the rows of a block
+func (m *TrackerModel) block(block Block) [][]TrackRow {
+	rows := make([][]TrackRow, block.Tracks)
+	for i := range rows {
+		rows[i] = slices.Clone(m.Tracks[block.Track+i].Rows[block.Row : block.Row+block.Rows])
+	}
+	return rows
+}
+
+// setBlock writes rows per track starting at the track and row, clipping at the pattern edges
+func (m *TrackerModel) setBlock(track, row int, rows [][]TrackRow) {
+	for i, column := range rows {
+		if track+i >= m.NumTracks {
+			break
+		}
+		copy(m.Tracks[track+i].Rows[row:], column)
+	}
+}
+
+// Copy returns the selected rows
+func (m *TrackerModel) Copy() Clipboard {
+	return Clipboard{Tracks: m.block(m.Selection())}
+}
+
+// Cut returns the selected rows and clears them, along with the command to undo it
+func (m *TrackerModel) Cut() (Clipboard, Command) {
+	block := m.Selection()
+	clipboard := Clipboard{Tracks: m.block(block)}
+
+	cleared := make([][]TrackRow, block.Tracks)
+	for i := range cleared {
+		cleared[i] = emptyRows(block.Rows)
+	}
+
+	m.setBlock(block.Track, block.Row, cleared)
+	m.ClearSelection()
+	return clipboard, BlockEdit(block.Track, block.Row, clipboard.Tracks, cleared)
+}
+
+// Paste writes the clipboard at the cursor and returns the command to undo it
+func (m *TrackerModel) Paste(clipboard Clipboard, mode PasteMode) Command {
+	if clipboard.Empty() {
+		return nil
+	}
+
+	track, row := m.CursorTrack, m.CursorRow
+	tracks := min(len(clipboard.Tracks), m.NumTracks-track)
+	rows := len(clipboard.Tracks[0])
+
+	// Inserting moves everything down to the end of the pattern
+	block := Block{Track: track, Row: row, Tracks: tracks, Rows: min(rows, m.NumRows-row)}
+	if mode == PasteInsert {
+		block.Rows = m.NumRows - row
+	}
+
+	before := m.block(block)
+	after := m.block(block)
+	for i := range after {
+		pasted := clipboard.Tracks[i]
+
+		switch mode {
+		case PasteInsert:
+			after[i] = slices.Concat(pasted, after[i])[:block.Rows]
+		case PasteOverwrite:
+			copy(after[i], pasted)
+		case PasteMix:
+			for j := range min(len(pasted), len(after[i])) {
+				after[i][j] = mixRow(after[i][j], pasted[j])
+			}
+		}
+	}
+
+	m.setBlock(track, row, after)
+	m.ClearSelection()
+	return BlockEdit(track, row, before, after)
+}
+
+// mixRow fills the empty columns of a row from the pasted row
+func mixRow(row, pasted TrackRow) TrackRow {
+	if audio.IsOff(row.Note) {
+		row.Note = pasted.Note
+	}
+	if row.Volume == 0 {
+		row.Volume = pasted.Volume
+	}
+	if isEmptyEffect(row.Effect) {
+		row.Effect = pasted.Effect
+	}
+	return row
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+func TestBlockSelection(t *testing.T) {
+	tracker := NewTracker(4, 16, 0, 20)
+	tracker.CursorTrack, tracker.CursorRow = 2, 5
+
+	tracker.Update(tea.KeyMsg{Type: tea.KeyShiftUp})
+	tracker.Update(tea.KeyMsg{Type: tea.KeyShiftUp})
+	tracker.Update(tea.KeyMsg{Type: tea.KeyShiftLeft})
+
+	want := Block{Track: 1, Row: 3, Tracks: 2, Rows: 3}
+	if got := tracker.Selection(); got != want {
+		t.Errorf("Expected selection %+v, got %+v", want, got)
+	}
+
+	// Moving without shift drops the selection
+	tracker.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if tracker.HasSelection() {
+		t.Error("Expected plain movement to clear the selection")
+	}
+}
+
+func TestPasteModes(t *testing.T) {
+	c4 := audio.NewNote(audio.BaseC, audio.Octave4)
+	g4 := audio.NewNote(audio.BaseG, audio.Octave4)
+
+	tracker := NewTracker(2, 8, 0, 20)
+	tracker.Tracks[0].Rows[0] = TrackRow{Note: c4, Volume: 64, Effect: "A01"}
+	tracker.Tracks[0].Rows[1] = TrackRow{Note: audio.Off(), Volume: 32, Effect: "---"}
+
+	tracker.CursorRow = 0
+	tracker.startSelection()
+	tracker.CursorRow = 1
+	clipboard := tracker.Copy()
+
+	// Mix paste only fills the empty columns
+	tracker.Tracks[1].Rows[0] = TrackRow{Note: g4, Volume: 0, Effect: "---"}
+	tracker.CursorTrack, tracker.CursorRow = 1, 0
+	tracker.Paste(clipboard, PasteMix)
+	if got := tracker.Tracks[1].Rows[0]; got != (TrackRow{Note: g4, Volume: 64, Effect: "A01"}) {
+		t.Errorf("Expected mixed row, got %+v", got)
+	}
+
+	// Insert paste shifts the rows below down
+	tracker.CursorTrack, tracker.CursorRow = 1, 0
+	undo := tracker.Paste(clipboard, PasteInsert)
+	if got := tracker.Tracks[1].Rows[2]; got.Note != g4 {
+		t.Errorf("Expected G-4 moved to row 2, got %+v", got)
+	}
+
+	undo.Undo(tracker)
+	if got := tracker.Tracks[1].Rows[0]; got.Note != g4 || got.Volume != 64 {
+		t.Errorf("Expected undo to restore the mixed row, got %+v", got)
+	}
+
+	// Overwrite paste clipped at the last row
+	tracker.CursorTrack, tracker.CursorRow = 0, 7
+	tracker.Paste(clipboard, PasteOverwrite)
+	if got := tracker.Tracks[0].Rows[7]; got.Note != c4 {
+		t.Errorf("Expected C-4 in the last row, got %+v", got)
+	}
+}
+
+func TestClipboardText(t *testing.T) {
+	c4 := audio.NewNote(audio.BaseC, audio.Octave4)
+	fs5 := audio.NewNote(audio.BaseFs, audio.Octave5)
+
+	clipboard := Clipboard{Tracks: [][]TrackRow{
+		{{Note: c4, Volume: 64, Effect: "A01"}, emptyRow()},
+		{{Note: fs5, Volume: 8, Effect: "---"}, {Note: audio.Off(), Volume: 32, Effect: "---"}},
+	}}
+
+	text := clipboard.Text()
+	want := "tetrackt pattern\n|C-4 64 A01|F#5 08 ---\n|--- .. ---|--- 32 ---\n"
+	if text != want {
+		t.Errorf("Expected text %q, got %q", want, text)
+	}
+
+	parsed, err := ParseClipboard(text)
+	if err != nil {
+		t.Fatalf("Expected text to parse, got %v", err)
+	}
+	if !reflect.DeepEqual(parsed, clipboard) {
+		t.Errorf("Expected roundtrip %+v, got %+v", clipboard, parsed)
+	}
+
+	// Short rows are padded, the header is optional
+	parsed, err = ParseClipboard("|C-4 64 A01|F#5\n|---\n")
+	if err != nil {
+		t.Fatalf("Expected short rows to parse, got %v", err)
+	}
+	if got := parsed.Tracks[1][1]; got != emptyRow() {
+		t.Errorf("Expected padded empty row, got %+v", got)
+	}
+
+	// Effects are case insensitive like typing them
+	parsed, err = ParseClipboard("|C-4 .. f0a\n")
+	if err != nil || parsed.Tracks[0][0].Effect != "F0A" {
+		t.Errorf("Expected effect F0A, got %+v (%v)", parsed, err)
+	}
+
+	for _, text := range []string{"", "hello", "|H-4 .. ---", "|C-4 99 ---", "|C-4 .. hello", "|C-4 .. A0G", "|C-4 .. -05", "|C-4 .. A1"} {
+		if _, err := ParseClipboard(text); err == nil {
+			t.Errorf("Expected error for %q", text)
+		}
+	}
+}
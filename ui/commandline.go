@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCompletions bounds the number of completions shown below the command line
+const maxCompletions = 8
+
+// CommandLineModel is the : command line, tab completes the command name
+type CommandLineModel struct {
+	Input    string
+	Error    string
+	visible  bool
+	commands []string
+}
+
+// CommandEntered is sent when the user confirms a command line
+type CommandEntered struct {
+	Name string
+	Args []string
+}
+
+// NewCommandLine creates a command line completing the given command names
+func NewCommandLine(commands []string) *CommandLineModel {
+	return &CommandLineModel{commands: commands}
+}
+
+// Show opens the command line with the input prefilled, e.g. "goto "
+func (m *CommandLineModel) Show(input string) {
+	m.visible = true
+	m.Input = input
+	m.Error = ""
+}
+
+// Hide closes the command line
+func (m *CommandLineModel) Hide() {
+	m.visible = false
+	m.Input = ""
+	m.Error = ""
+}
+
+// SetError sets an error message to display below the command line
+func (m *CommandLineModel) SetError(err string) {
+	m.Error = err
+}
+
+// IsVisible returns true if the command line is open
+func (m *CommandLineModel) IsVisible() bool {
+	return m.visible
+}
+
+// ParseCommand splits a command line into the command name and its arguments,
+// reporting false for an empty line
+func ParseCommand(input string) (CommandEntered, bool) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return CommandEntered{}, false
+	}
+	return CommandEntered{Name: fields[0], Args: fields[1:]}, true
+}
+
+// Completions returns the commands matching the typed name, those starting with it first,
+// followed by those containing its letters in order
+func (m CommandLineModel) Completions() []string {
+	name := strings.TrimLeft(m.Input, " ")
+	if strings.Contains(name, " ") {
+		// The name is complete once arguments are typed
+		return nil
+	}
+
+	var prefixed, fuzzy []string
+	for _, command := range m.commands {
+		switch {
+		case strings.HasPrefix(command, name):
+			prefixed = append(prefixed, command)
+		case isSubsequence(name, command):
+			fuzzy = append(fuzzy, command)
+		}
+	}
+	return append(prefixed, fuzzy...)
+}
+
+// isSubsequence reports whether the letters of s appear in t in the same order
+func isSubsequence(s, t string) bool {
+	for _, r := range s {
+		idx := strings.IndexRune(t, r)
+		if idx < 0 {
+			return false
+		}
+		t = t[idx+1:]
+	}
+	return true
+}
+
+// Init initializes the command line (required by Bubble Tea)
+func (m CommandLineModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles keyboard input for the command line
+func (m CommandLineModel) Update(msg tea.Msg) (CommandLineModel, tea.Cmd) {
+	if !m.IsVisible() {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			command, ok := ParseCommand(m.Input)
+			if !ok {
+				m.Hide()
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				return command
+			}
+
+		case "esc":
+			m.Hide()
+			return m, nil
+
+		case "tab":
+			if completions := m.Completions(); len(completions) > 0 {
+				m.Input = completions[0] + " "
+				m.Error = ""
+			}
+			return m, nil
+
+		case "backspace":
+			// Backspace on an empty line closes it like in vim
+			if len(m.Input) == 0 {
+				m.Hide()
+				return m, nil
+			}
+			m.Input = m.Input[:len(m.Input)-1]
+			m.Error = ""
+			return m, nil
+
+		default:
+			// Type into the command line (only printable ASCII characters)
+			if len(msg.String()) == 1 && msg.String()[0] >= ' ' && msg.String()[0] <= '~' {
+				m.Input += msg.String()
+				m.Error = ""
+			}
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the command line with the completions of the typed name or the error
+func (m CommandLineModel) View() string {
+	if !m.IsVisible() {
+		return ""
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf(":%s_", m.Input))
+
+	switch completions := m.Completions(); {
+	case m.Error != "":
+		content.WriteString(fmt.Sprintf("  Error: %s", m.Error))
+	case len(completions) > 0 && m.Input != "":
+		if len(completions) > maxCompletions {
+			completions = append(completions[:maxCompletions], "…")
+		}
+		content.WriteString("  [Tab] " + strings.Join(completions, " | "))
+	}
+
+	return content.String()
+}
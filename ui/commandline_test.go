@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseCommand(t *testing.T) {
+	command, ok := ParseCommand("  goto   0x30 ")
+	if !ok || command.Name != "goto" || !slices.Equal(command.Args, []string{"0x30"}) {
+		t.Errorf("Expected goto with argument 0x30, got %+v", command)
+	}
+
+	if _, ok := ParseCommand("   "); ok {
+		t.Error("Expected an empty command line not to parse")
+	}
+}
+
+func TestCommandLineCompletion(t *testing.T) {
+	commandLine := NewCommandLine([]string{"bpm", "rows", "transpose", "transpose-up", "reverse"})
+	commandLine.Show("")
+
+	for _, r := range "tr" {
+		*commandLine, _ = commandLine.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := commandLine.Completions(); !slices.Equal(got, []string{"transpose", "transpose-up"}) {
+		t.Errorf("Expected the commands starting with tr, got %v", got)
+	}
+
+	// Letters in order also match, after the commands starting with them
+	commandLine.Input = "rs"
+	if got := commandLine.Completions(); !slices.Equal(got, []string{"rows", "transpose", "transpose-up", "reverse"}) {
+		t.Errorf("Expected fuzzy matches, got %v", got)
+	}
+
+	commandLine.Input = "tra"
+	*commandLine, _ = commandLine.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if commandLine.Input != "transpose " {
+		t.Errorf("Expected tab to complete the name, got %q", commandLine.Input)
+	}
+
+	for _, r := range "+3" {
+		*commandLine, _ = commandLine.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	_, cmd := commandLine.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Expected enter to send the command")
+	}
+	if got, ok := cmd().(CommandEntered); !ok || got.Name != "transpose" || !slices.Equal(got.Args, []string{"+3"}) {
+		t.Errorf("Expected transpose +3, got %+v", got)
+	}
+
+	*commandLine, _ = commandLine.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if commandLine.IsVisible() {
+		t.Error("Expected esc to close the command line")
+	}
+}
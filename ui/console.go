@@ -0,0 +1,484 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// ConsoleEditField represents which channel parameter is being edited in the mixer console
+type ConsoleEditField int
+
+const (
+	ConsoleGain ConsoleEditField = iota
+	ConsolePan
+	ConsoleMute
+	ConsoleSolo
+	ConsoleReverbSend
+	ConsoleRoute
+	ConsoleDuckKey
+	ConsoleDuckThreshold
+	ConsoleDuckAmount
+	ConsoleDuckAttack
+	ConsoleDuckRelease
+	consoleFieldCount
+)
+
+const (
+	maxLevel       = 6 // highest fader and level position in dB
+	maxDuckAmount  = 48
+	maxDuckAttack  = 100
+	maxDuckRelease = 1000
+	meterHeight    = 8
+	meterFloorDb   = -48.0
+	meterDecay     = 0.7 // falloff of the displayed level per meter update
+	stripWidth     = 10
+)
+
+var (
+	meterStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#00e676"))
+	meterHotStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffeb3b"))
+	meterClipStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff1744"))
+	muteActiveStyle = lipgloss.NewStyle().Background(lipgloss.Color("#ff9800")).Foreground(lipgloss.Color("#000000"))
+	soloActiveStyle = lipgloss.NewStyle().Background(lipgloss.Color("#ffeb3b")).Foreground(lipgloss.Color("#000000"))
+	meterBlocks     = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+)
+
+// ConsoleModel is the mixer console showing a channel strip for every track followed
+// by a strip for every submix bus
+type ConsoleModel struct {
+	Channels      []audio.Channel // channel settings of all tracks, set by main
+	Buses         []audio.Bus     // submix buses, set by main
+	Current       int             // strip being edited, tracks first, then buses
+	levels        []float64       // displayed meter levels
+	consoleField  ConsoleEditField
+	selectedStyle lipgloss.Style
+}
+
+// ChannelUpdated is sent when a channel strip setting changes
+type ChannelUpdated struct {
+	Track   int
+	Channel audio.Channel
+}
+
+// ChannelSelected is sent when another channel strip is selected
+type ChannelSelected struct {
+	Track int
+}
+
+// BusUpdated is sent when a bus strip setting changes
+type BusUpdated struct {
+	Index int
+	Bus   audio.Bus
+}
+
+// BusesChanged is sent when a bus is added or removed, channels are rerouted accordingly
+type BusesChanged struct {
+	Buses    []audio.Bus
+	Channels []audio.Channel
+}
+
+func NewConsoleModel(selectedStyle lipgloss.Style) *ConsoleModel {
+	return &ConsoleModel{
+		consoleField:  ConsoleGain,
+		selectedStyle: selectedStyle,
+	}
+}
+
+func (m *ConsoleModel) Init() tea.Cmd {
+	return nil
+}
+
+// SelectedBus returns the number of the selected bus strip, 0 when a track strip is selected
+func (m *ConsoleModel) SelectedBus() int {
+	if m.Current < len(m.Channels) || m.Current >= len(m.Channels)+len(m.Buses) {
+		return 0
+	}
+	return m.Current - len(m.Channels) + 1
+}
+
+// SetLevels feeds new peak levels from the engine into the meters
+func (m *ConsoleModel) SetLevels(levels []float64) {
+	if len(m.levels) != len(levels) {
+		m.levels = make([]float64, len(levels))
+	}
+
+	for i, level := range levels {
+		m.levels[i] = max(level, m.levels[i]*meterDecay)
+	}
+}
+
+func (m *ConsoleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
+			m.moveField(-1)
+			return m, nil
+		case ActionNextField:
+			m.moveField(1)
+			return m, nil
+		case ActionPreviousStrip:
+			return m, m.selectStrip(m.Current - 1)
+		case ActionNextStrip:
+			return m, m.selectStrip(m.Current + 1)
+		case ActionAdd:
+			return m, m.addBus()
+		case ActionRemove:
+			return m, m.removeBus()
+		case ActionDecrease:
+			m.adjust(-1)
+		case ActionDecreaseMore:
+			m.adjust(-10)
+		case ActionIncrease:
+			m.adjust(1)
+		case ActionIncreaseMore:
+			m.adjust(10)
+		case ActionToggle:
+			m.adjust(0)
+		default:
+			return m, nil
+		}
+
+		if bus := m.SelectedBus(); bus > 0 {
+			idx, settings := bus-1, m.Buses[bus-1]
+			return m, func() tea.Msg { return BusUpdated{Index: idx, Bus: settings} }
+		}
+
+		if m.Current < 0 || m.Current >= len(m.Channels) {
+			return m, nil
+		}
+
+		track, channel := m.Current, m.Channels[m.Current]
+		return m, func() tea.Msg { return ChannelUpdated{Track: track, Channel: channel} }
+	}
+
+	return m, nil
+}
+
+// fieldApplies reports whether a field exists on the selected strip, bus strips have no pan, solo, send or routing
+func (m *ConsoleModel) fieldApplies(field ConsoleEditField) bool {
+	if m.SelectedBus() == 0 {
+		return true
+	}
+
+	switch field {
+	case ConsolePan, ConsoleSolo, ConsoleReverbSend, ConsoleRoute:
+		return false
+	}
+	return true
+}
+
+// moveField selects the next field of the selected strip in the given direction
+func (m *ConsoleModel) moveField(direction int) {
+	for {
+		m.consoleField = (m.consoleField + ConsoleEditField(direction) + consoleFieldCount) % consoleFieldCount
+		if m.fieldApplies(m.consoleField) {
+			return
+		}
+	}
+}
+
+// selectStrip selects a track or bus strip, selecting a track also moves the tracker cursor
+func (m *ConsoleModel) selectStrip(strip int) tea.Cmd {
+	if strip < 0 || strip >= len(m.Channels)+len(m.Buses) {
+		return nil
+	}
+
+	m.Current = strip
+	if !m.fieldApplies(m.consoleField) {
+		m.consoleField = ConsoleGain
+	}
+
+	if strip >= len(m.Channels) {
+		return nil
+	}
+	return func() tea.Msg { return ChannelSelected{Track: strip} }
+}
+
+// addBus appends a new bus and selects its strip
+func (m *ConsoleModel) addBus() tea.Cmd {
+	m.Buses = append(slices.Clone(m.Buses), audio.NewBus(fmt.Sprintf("Bus %d", len(m.Buses)+1)))
+	m.selectStrip(len(m.Channels) + len(m.Buses) - 1)
+
+	buses, channels := m.Buses, m.Channels
+	return func() tea.Msg { return BusesChanged{Buses: buses, Channels: channels} }
+}
+
+// removeBus removes the selected bus, its channels are routed to the master
+func (m *ConsoleModel) removeBus() tea.Cmd {
+	bus := m.SelectedBus()
+	if bus == 0 {
+		return nil
+	}
+
+	m.Buses = slices.Delete(slices.Clone(m.Buses), bus-1, bus)
+	m.Channels = slices.Clone(m.Channels)
+	for i := range m.Channels {
+		switch {
+		case m.Channels[i].Bus == bus:
+			m.Channels[i].Bus = 0
+		case m.Channels[i].Bus > bus:
+			m.Channels[i].Bus--
+		}
+	}
+	m.Current = min(m.Current, len(m.Channels)+len(m.Buses)-1)
+
+	buses, channels := m.Buses, m.Channels
+	return func() tea.Msg { return BusesChanged{Buses: buses, Channels: channels} }
+}
+
+// adjust changes the selected field of the current strip, steps of 0 toggle switches
+func (m *ConsoleModel) adjust(steps int) {
+	if bus := m.SelectedBus(); bus > 0 {
+		m.Buses = slices.Clone(m.Buses)
+		settings := &m.Buses[bus-1]
+
+		switch m.consoleField {
+		case ConsoleGain:
+			settings.Gain = stepLevel(settings.Gain, steps)
+		case ConsoleMute:
+			settings.Mute = !settings.Mute
+		default:
+			m.adjustDucker(&settings.Ducker, steps)
+		}
+		return
+	}
+
+	if m.Current < 0 || m.Current >= len(m.Channels) {
+		return
+	}
+
+	channel := &m.Channels[m.Current]
+
+	switch m.consoleField {
+	case ConsoleGain:
+		channel.Gain = stepLevel(channel.Gain, steps)
+	case ConsolePan:
+		channel.Pan = stepPan(channel.Pan, steps)
+	case ConsoleMute:
+		channel.Mute = !channel.Mute
+	case ConsoleSolo:
+		channel.Solo = !channel.Solo
+	case ConsoleReverbSend:
+		channel.ReverbSend = stepPercent(channel.ReverbSend, steps, 1)
+	case ConsoleRoute:
+		channel.Bus = (channel.Bus + sign(steps) + len(m.Buses) + 1) % (len(m.Buses) + 1)
+	default:
+		m.adjustDucker(&channel.Ducker, steps)
+	}
+}
+
+// adjustDucker changes the selected sidechain ducker field
+func (m *ConsoleModel) adjustDucker(ducker *audio.Ducker, steps int) {
+	switch m.consoleField {
+	case ConsoleDuckKey:
+		key := (ducker.Key + sign(steps) + len(m.Channels) + 1) % (len(m.Channels) + 1)
+		// Picking a key for a ducker that was never set up starts from typical settings
+		if *ducker == (audio.Ducker{}) {
+			*ducker = audio.NewDucker(key)
+		}
+		ducker.Key = key
+	case ConsoleDuckThreshold:
+		ducker.Threshold = clamp(ducker.Threshold+float64(steps), audio.MinLevel, 0)
+	case ConsoleDuckAmount:
+		ducker.Amount = clamp(ducker.Amount+float64(steps), 0, maxDuckAmount)
+	case ConsoleDuckAttack:
+		ducker.Attack = clamp(ducker.Attack+float64(steps), 0, maxDuckAttack)
+	case ConsoleDuckRelease:
+		ducker.Release = clamp(ducker.Release+float64(steps)*10, 10, maxDuckRelease)
+	}
+}
+
+func (m *ConsoleModel) View() string {
+	strips := make([]string, 0, len(m.Channels)+len(m.Buses))
+	for i := range m.Channels {
+		strips = append(strips, m.stripView(i))
+	}
+	for i := range m.Buses {
+		strips = append(strips, m.busStripView(i))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, strips...)
+}
+
+// stripView renders the channel strip of one track
+func (m *ConsoleModel) stripView(track int) string {
+	channel := m.Channels[track]
+	current := track == m.Current
+	lineStyle := lipgloss.NewStyle().Width(stripWidth)
+
+	field := func(content string, field ConsoleEditField) string {
+		return lineStyle.Render(renderFieldSelected(content, current && m.consoleField == field, m.selectedStyle))
+	}
+
+	var lines []string
+
+	header := fmt.Sprintf("Track %d", track+1)
+	if current {
+		lines = append(lines, lineStyle.Render(headerStyle.UnsetPadding().Render(header)))
+	} else {
+		lines = append(lines, lineStyle.Render(rowNumStyle.Render(header)))
+	}
+
+	level := 0.0
+	if track < len(m.levels) {
+		level = m.levels[track]
+	}
+	lines = append(lines, meterLines(level)...)
+
+	lines = append(lines,
+		field(formatGain(channel.Gain), ConsoleGain),
+		field(formatPan(channel.Pan), ConsolePan),
+		field(renderSwitch("M", channel.Mute, muteActiveStyle), ConsoleMute),
+		field(renderSwitch("S", channel.Solo, soloActiveStyle), ConsoleSolo),
+		field(fmt.Sprintf("Rv %3d%%", int(math.Round(channel.ReverbSend*100))), ConsoleReverbSend),
+		field("→"+m.busName(channel.Bus), ConsoleRoute),
+	)
+	lines = append(lines, duckerLines(channel.Ducker, field)...)
+
+	return strings.Join(lines, "\n")
+}
+
+// busStripView renders the strip of a submix bus
+func (m *ConsoleModel) busStripView(idx int) string {
+	bus := m.Buses[idx]
+	current := m.SelectedBus() == idx+1
+	lineStyle := lipgloss.NewStyle().Width(stripWidth)
+
+	field := func(content string, field ConsoleEditField) string {
+		return lineStyle.Render(renderFieldSelected(content, current && m.consoleField == field, m.selectedStyle))
+	}
+
+	var lines []string
+
+	header := truncate(bus.Name, stripWidth-1)
+	if current {
+		lines = append(lines, lineStyle.Render(headerStyle.UnsetPadding().Render(header)))
+	} else {
+		lines = append(lines, lineStyle.Render(rowNumStyle.Render(header)))
+	}
+
+	level := 0.0
+	if strip := len(m.Channels) + idx; strip < len(m.levels) {
+		level = m.levels[strip]
+	}
+	lines = append(lines, meterLines(level)...)
+
+	lines = append(lines,
+		field(formatGain(bus.Gain), ConsoleGain),
+		lineStyle.Render(""),
+		field(renderSwitch("M", bus.Mute, muteActiveStyle), ConsoleMute),
+		lineStyle.Render(""),
+		lineStyle.Render(fmt.Sprintf("FX %d", len(bus.Inserts))),
+		lineStyle.Render("→Master"),
+	)
+	lines = append(lines, duckerLines(bus.Ducker, field)...)
+
+	return strings.Join(lines, "\n")
+}
+
+// duckerLines renders the sidechain ducker fields of a strip
+func duckerLines(ducker audio.Ducker, field func(string, ConsoleEditField) string) []string {
+	key := "SC off"
+	if ducker.Key > 0 {
+		key = fmt.Sprintf("SC T%d", ducker.Key)
+	}
+
+	return []string{
+		field(key, ConsoleDuckKey),
+		field(fmt.Sprintf("Th %+.0fdB", ducker.Threshold), ConsoleDuckThreshold),
+		field(fmt.Sprintf("Am %.0fdB", ducker.Amount), ConsoleDuckAmount),
+		field(fmt.Sprintf("At %.0fms", ducker.Attack), ConsoleDuckAttack),
+		field(fmt.Sprintf("Re %.0fms", ducker.Release), ConsoleDuckRelease),
+	}
+}
+
+// busName returns the display name of the bus with the given number, 0 is the master
+func (m *ConsoleModel) busName(bus int) string {
+	if bus < 1 || bus > len(m.Buses) {
+		return "Master"
+	}
+	return truncate(m.Buses[bus-1].Name, stripWidth-2)
+}
+
+// truncate shortens a label to at most width runes
+func truncate(label string, width int) string {
+	runes := []rune(label)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return label
+}
+
+// meterLines renders a vertical level meter, top line first
+func meterLines(level float64) []string {
+	db := meterFloorDb
+	if level > 0 {
+		db = max(20*math.Log10(level), meterFloorDb)
+	}
+
+	// Height of the bar in eighths of a line
+	eighths := int(math.Round((db - meterFloorDb) / -meterFloorDb * meterHeight * 8))
+
+	lines := make([]string, meterHeight)
+	for row := range meterHeight {
+		fromBottom := meterHeight - 1 - row
+		fill := min(max(eighths-fromBottom*8, 0), 8)
+
+		style := meterStyle
+		switch {
+		case level > 1 && row == 0:
+			style = meterClipStyle
+		case fromBottom >= meterHeight-2:
+			style = meterHotStyle
+		}
+
+		lines[row] = "   " + style.Render(strings.Repeat(meterBlocks[fill], 2))
+	}
+
+	return lines
+}
+
+// stepLevel moves a level in dB by steps of 0.5dB
+func stepLevel(level float64, steps int) float64 {
+	return clamp(level+float64(steps)*0.5, audio.MinLevel, maxLevel)
+}
+
+// stepPan moves a pan position by steps of 2%
+func stepPan(pan float64, steps int) float64 {
+	return clamp(math.Round(pan*100+float64(steps)*2)/100, -1, 1)
+}
+
+// renderSwitch renders an on/off button label
+func renderSwitch(label string, on bool, activeStyle lipgloss.Style) string {
+	if on {
+		return activeStyle.Render("[" + label + "]")
+	}
+	return "[" + label + "]"
+}
+
+// formatGain formats a fader position for display
+func formatGain(gain float64) string {
+	if gain <= audio.MinLevel {
+		return " -inf dB"
+	}
+	return fmt.Sprintf("%+5.1fdB", gain)
+}
+
+// formatPan formats a pan position for display
+func formatPan(pan float64) string {
+	percent := int(math.Round(pan * 100))
+	switch {
+	case percent < 0:
+		return fmt.Sprintf("L%d", -percent)
+	case percent > 0:
+		return fmt.Sprintf("R%d", percent)
+	}
+	return "C"
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// DrumEditField represents which drum parameter is being edited
+type DrumEditField int
+
+const (
+	DrumInstrument DrumEditField = iota
+	DrumPreset
+	DrumPitch
+	DrumEnd
+	DrumSweep
+	DrumDecay
+	DrumNoise
+	DrumTone
+	DrumColor
+	DrumClick
+	DrumBursts
+	drumFieldCount
+)
+
+const (
+	minDrumPitch = 20
+	maxDrumPitch = 2000
+	maxDrumSweep = 500
+	maxDrumDecay = 2000
+	maxBursts    = 8
+)
+
+// DrumModel edits the instrument type of the current track and its drum voice
+type DrumModel struct {
+	drumField     DrumEditField
+	Instrument    audio.InstrumentType
+	Drum          audio.Drum
+	selectedStyle lipgloss.Style
+}
+
+type DrumUpdated struct {
+	Instrument audio.InstrumentType
+	Drum       audio.Drum
+}
+
+func NewDrumModel(selectedStyle lipgloss.Style, instrument audio.InstrumentType, drum audio.Drum) *DrumModel {
+	return &DrumModel{
+		drumField:     DrumInstrument,
+		Instrument:    instrument,
+		Drum:          drum,
+		selectedStyle: selectedStyle,
+	}
+}
+
+func (m *DrumModel) Init() tea.Cmd {
+	return nil
+}
+
+// IsDrum reports whether the track plays the drum voice instead of the synth
+func (m *DrumModel) IsDrum() bool {
+	return m.Instrument == audio.DrumInstrument
+}
+
+func (m *DrumModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
+			m.drumField = (m.drumField - 1 + drumFieldCount) % drumFieldCount
+			return m, nil
+		case ActionNextField:
+			m.drumField = (m.drumField + 1) % drumFieldCount
+			return m, nil
+		case ActionDecrease:
+			m.adjust(-1)
+		case ActionDecreaseMore:
+			m.adjust(-10)
+		case ActionIncrease:
+			m.adjust(1)
+		case ActionIncreaseMore:
+			m.adjust(10)
+		default:
+			return m, nil
+		}
+
+		instrument, drum := m.Instrument, m.Drum
+		return m, func() tea.Msg { return DrumUpdated{Instrument: instrument, Drum: drum} }
+	}
+
+	return m, nil
+}
+
+// adjust changes the current field by the given number of steps
+func (m *DrumModel) adjust(steps int) {
+	drum := &m.Drum
+
+	switch m.drumField {
+	case DrumInstrument:
+		types := audio.InstrumentTypes
+		idx := max(slices.Index(types, m.Instrument), 0)
+		m.Instrument = types[(idx+sign(steps)+len(types))%len(types)]
+		// Tracks switched to drums for the first time start with a kick
+		if m.IsDrum() && drum.Preset == "" {
+			*drum = audio.NewDrum(audio.DrumKick)
+		}
+	case DrumPreset:
+		idx := max(slices.Index(audio.DrumPresets, drum.Preset), 0)
+		*drum = audio.NewDrum(audio.DrumPresets[(idx+sign(steps)+len(audio.DrumPresets))%len(audio.DrumPresets)])
+	case DrumPitch:
+		drum.Pitch = clamp(drum.Pitch+float64(steps), minDrumPitch, maxDrumPitch)
+	case DrumEnd:
+		drum.End = clamp(drum.End+float64(steps), minDrumPitch, maxDrumPitch)
+	case DrumSweep:
+		drum.Sweep = clamp(drum.Sweep+float64(steps)*5, 0, maxDrumSweep)
+	case DrumDecay:
+		drum.Decay = clamp(drum.Decay+float64(steps)*5, 0, maxDrumDecay)
+	case DrumNoise:
+		drum.Noise = clamp(drum.Noise+float64(steps)*5, 0, maxDrumDecay)
+	case DrumTone:
+		drum.Tone = stepPercent(drum.Tone, steps, 1)
+	case DrumColor:
+		drum.Color = stepPercent(drum.Color, steps, 1)
+	case DrumClick:
+		drum.Click = stepPercent(drum.Click, steps, 1)
+	case DrumBursts:
+		drum.Bursts = min(max(drum.Bursts+sign(steps), 1), maxBursts)
+	}
+}
+
+func (m *DrumModel) View() string {
+	drum := m.Drum
+
+	view := strings.Builder{}
+	view.WriteString("Drum:\n")
+
+	instrument := m.Instrument
+	if instrument == "" {
+		instrument = audio.SynthInstrument
+	}
+	view.WriteString(renderFieldSelected("Type: "+string(instrument), m.drumField == DrumInstrument, m.selectedStyle) + "\n")
+
+	preset := string(drum.Preset)
+	if preset == "" {
+		preset = "-"
+	}
+	view.WriteString(renderFieldSelected("Preset: "+preset, m.drumField == DrumPreset, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Pitch: %4.0fHz", drum.Pitch), m.drumField == DrumPitch, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("End:   %4.0fHz", drum.End), m.drumField == DrumEnd, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Sweep: %4.0fms", drum.Sweep), m.drumField == DrumSweep, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Decay: %4.0fms", drum.Decay), m.drumField == DrumDecay, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Noise: %4.0fms", drum.Noise), m.drumField == DrumNoise, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Tone", drum.Tone, m.drumField == DrumTone, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Color", drum.Color, m.drumField == DrumColor, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Click", drum.Click, m.drumField == DrumClick, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Bursts: %d", max(drum.Bursts, 1)), m.drumField == DrumBursts, m.selectedStyle))
+
+	return view.String()
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+const maxInserts = 4
+
+// insertParam represents a parameter of an insert effect
+type insertParam int
+
+const (
+	insertType insertParam = iota
+	insertDrive
+	insertTone
+	insertRate
+	insertDepth
+	insertFeedback
+	insertMix
+)
+
+var insertTypes = []audio.InsertType{audio.Overdrive, audio.Chorus, audio.Flanger}
+
+// insertField addresses one editable line of the effects panel
+type insertField struct {
+	slot  int
+	param insertParam
+}
+
+// EffectsModel edits the insert effect chain of the current track or of a submix bus
+type EffectsModel struct {
+	Inserts       []audio.Insert
+	Bus           string // name of the edited bus, empty when editing the current track
+	field         int    // index into fields()
+	selectedStyle lipgloss.Style
+}
+
+type EffectsUpdated struct {
+	Inserts []audio.Insert
+}
+
+func NewEffectsModel(selectedStyle lipgloss.Style, inserts []audio.Insert) *EffectsModel {
+	return &EffectsModel{
+		Inserts:       inserts,
+		selectedStyle: selectedStyle,
+	}
+}
+
+// SetInserts shows another insert chain, keeping the selection within it
+func (m *EffectsModel) SetInserts(inserts []audio.Insert) {
+	m.Inserts = inserts
+	m.field = max(min(m.field, len(m.fields())-1), 0)
+}
+
+func (m *EffectsModel) Init() tea.Cmd {
+	return nil
+}
+
+// insertParams returns the parameters shown for an effect type
+func insertParams(insertType audio.InsertType) []insertParam {
+	switch insertType {
+	case audio.Chorus, audio.Flanger:
+		return []insertParam{insertRate, insertDepth, insertFeedback, insertMix}
+	default:
+		return []insertParam{insertDrive, insertTone, insertMix}
+	}
+}
+
+// fields flattens the chain into the list of editable lines
+func (m *EffectsModel) fields() []insertField {
+	var fields []insertField
+	for slot, insert := range m.Inserts {
+		fields = append(fields, insertField{slot: slot, param: insertType})
+		for _, param := range insertParams(insert.Type) {
+			fields = append(fields, insertField{slot: slot, param: param})
+		}
+	}
+	return fields
+}
+
+func (m *EffectsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ActionMsg:
+		fields := m.fields()
+
+		switch msg.Action {
+		case ActionPreviousField:
+			if len(fields) > 0 {
+				m.field = (m.field - 1 + len(fields)) % len(fields)
+			}
+			return m, nil
+		case ActionNextField:
+			if len(fields) > 0 {
+				m.field = (m.field + 1) % len(fields)
+			}
+			return m, nil
+		case ActionAdd:
+			// Add an overdrive after the selected insert
+			if len(m.Inserts) >= maxInserts {
+				return m, nil
+			}
+			slot := len(m.Inserts)
+			if m.field < len(fields) {
+				slot = fields[m.field].slot + 1
+			}
+			m.Inserts = slices.Insert(slices.Clone(m.Inserts), slot, audio.NewInsert(audio.Overdrive))
+			m.selectSlot(slot)
+		case ActionRemove:
+			// Remove the selected insert
+			if m.field >= len(fields) {
+				return m, nil
+			}
+			slot := fields[m.field].slot
+			m.Inserts = slices.Delete(slices.Clone(m.Inserts), slot, slot+1)
+			m.selectSlot(min(slot, len(m.Inserts)-1))
+		case ActionDecrease:
+			m.adjust(fields, -1)
+		case ActionDecreaseMore:
+			m.adjust(fields, -10)
+		case ActionIncrease:
+			m.adjust(fields, 1)
+		case ActionIncreaseMore:
+			m.adjust(fields, 10)
+		default:
+			return m, nil
+		}
+
+		inserts := m.Inserts
+		return m, func() tea.Msg { return EffectsUpdated{Inserts: inserts} }
+	}
+
+	return m, nil
+}
+
+// selectSlot moves the selection to the type line of an insert
+func (m *EffectsModel) selectSlot(slot int) {
+	m.field = 0
+	for i, field := range m.fields() {
+		if field.slot == slot && field.param == insertType {
+			m.field = i
+			return
+		}
+	}
+}
+
+// adjust changes the selected parameter by the given number of steps
+func (m *EffectsModel) adjust(fields []insertField, steps int) {
+	if m.field >= len(fields) {
+		return
+	}
+
+	field := fields[m.field]
+	m.Inserts = slices.Clone(m.Inserts)
+	insert := &m.Inserts[field.slot]
+
+	switch field.param {
+	case insertType:
+		// Switching the type resets the parameters to the defaults of the new effect
+		idx := (slices.Index(insertTypes, insert.Type) + sign(steps) + len(insertTypes)) % len(insertTypes)
+		*insert = audio.NewInsert(insertTypes[idx])
+	case insertDrive:
+		insert.Drive = stepPercent(insert.Drive, steps, 1)
+	case insertTone:
+		insert.Tone = stepPercent(insert.Tone, steps, 1)
+	case insertRate:
+		insert.Rate = stepPercent(insert.Rate, steps, 1)
+	case insertDepth:
+		insert.Depth = stepPercent(insert.Depth, steps, 1)
+	case insertFeedback:
+		insert.Feedback = stepPercent(insert.Feedback, steps, 0.9)
+	case insertMix:
+		insert.Mix = stepPercent(insert.Mix, steps, 1)
+	}
+}
+
+func (m *EffectsModel) View() string {
+	view := strings.Builder{}
+	if m.Bus != "" {
+		view.WriteString("Bus FX: " + m.Bus)
+	} else {
+		view.WriteString("Track FX:")
+	}
+
+	if len(m.Inserts) == 0 {
+		view.WriteString("\n(empty)")
+		return view.String()
+	}
+
+	for i, field := range m.fields() {
+		insert := m.Inserts[field.slot]
+		selected := i == m.field
+
+		view.WriteString("\n")
+		switch field.param {
+		case insertType:
+			view.WriteString(renderFieldSelected(fmt.Sprintf("%d: %s", field.slot+1, insert.Type), selected, m.selectedStyle))
+		case insertDrive:
+			view.WriteString(RenderKnobSelected("  Drive", insert.Drive, selected, m.selectedStyle))
+		case insertTone:
+			view.WriteString(RenderKnobSelected("  Tone", insert.Tone, selected, m.selectedStyle))
+		case insertRate:
+			view.WriteString(RenderKnobSelected("  Rate", insert.Rate, selected, m.selectedStyle))
+		case insertDepth:
+			view.WriteString(RenderKnobSelected("  Depth", insert.Depth, selected, m.selectedStyle))
+		case insertFeedback:
+			view.WriteString(RenderKnobSelected("  Feedback", insert.Feedback, selected, m.selectedStyle))
+		case insertMix:
+			view.WriteString(RenderKnobSelected("  Mix", insert.Mix, selected, m.selectedStyle))
+		}
+	}
+
+	return view.String()
+}
@@ -69,27 +69,27 @@ func (m *EnvelopeModel) Update(msg tea.Msg) (*EnvelopeModel, tea.Cmd) {
 		}
 	}
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "up":
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
 			// Move to previous envelope field
 			m.envelopeField = (m.envelopeField - 1 + 4) % 4
-		case "down":
+		case ActionNextField:
 			// Move to next envelope field
 			m.envelopeField = (m.envelopeField + 1) % 4
-		case "left":
+		case ActionDecrease:
 			// Decrease value by 1%
 			m.adjustEnvelopeValue(-0.01)
-		case "shift+left":
+		case ActionDecreaseMore:
 			// Decrease value by 10%
 			m.adjustEnvelopeValue(-0.10)
-		case "right":
+		case ActionIncrease:
 			// Increase value by 1%
 			m.adjustEnvelopeValue(0.01)
-		case "shift+right":
+		case ActionIncreaseMore:
 			// Increase value by 10%
 			m.adjustEnvelopeValue(0.10)
-		case ".":
+		case ActionPresets:
 			m.ShowModal = !m.ShowModal
 		}
 	}
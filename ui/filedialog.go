@@ -15,6 +15,9 @@ const (
 	ModeHidden FileDialogMode = iota
 	ModeSave
 	ModeLoad
+	ModeExport
+	ModeTuning
+	ModeSfx
 )
 
 // FileDialogModel represents the file dialog component state
@@ -62,6 +65,15 @@ func (m *FileDialogModel) Hide() {
 	m.cursorPosition = 0
 }
 
+// Submit shows the dialog in the mode and confirms the filename as if it was typed,
+// errors of the confirmation are shown in the open dialog
+func (m *FileDialogModel) Submit(mode FileDialogMode, filename string) tea.Cmd {
+	m.Show(mode, filename)
+	var cmd tea.Cmd
+	*m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return cmd
+}
+
 // SetError sets an error message to display in the dialog
 func (m *FileDialogModel) SetError(err string) {
 	m.Error = err
@@ -72,6 +84,17 @@ func (m *FileDialogModel) IsVisible() bool {
 	return m.Mode != ModeHidden
 }
 
+// Extension returns the file extension for the current mode
+func (m *FileDialogModel) Extension() string {
+	switch m.Mode {
+	case ModeExport, ModeSfx:
+		return ".wav"
+	case ModeTuning:
+		return ".scl"
+	}
+	return ".yaml"
+}
+
 // Init initializes the file dialog (required by Bubble Tea)
 func (m FileDialogModel) Init() tea.Cmd {
 	return nil
@@ -95,9 +118,10 @@ func (m FileDialogModel) Update(msg tea.Msg) (FileDialogModel, tea.Cmd) {
 				return m, nil
 			}
 
-			// Auto-append .yaml extension if not present
-			if !strings.HasSuffix(filename, ".yaml") {
-				filename += ".yaml"
+			// Auto-append extension if not present, tunings also accept keyboard mappings
+			keyboardMapping := m.Mode == ModeTuning && strings.HasSuffix(filename, ".kbm")
+			if ext := m.Extension(); !strings.HasSuffix(filename, ext) && !keyboardMapping {
+				filename += ext
 			}
 
 			// Clear dialog state and return confirmation message
@@ -144,6 +168,12 @@ func (m FileDialogModel) View() string {
 		dialogTitle = "Save Song"
 	case ModeLoad:
 		dialogTitle = "Load Song"
+	case ModeExport:
+		dialogTitle = "Export WAV"
+	case ModeTuning:
+		dialogTitle = "Load Tuning (.scl/.kbm)"
+	case ModeSfx:
+		dialogTitle = "Export Sound FX WAV"
 	default:
 		dialogTitle = "File Dialog"
 	}
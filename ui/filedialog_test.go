@@ -109,3 +109,22 @@ func TestFileDialogEmptyFilename(t *testing.T) {
 		t.Error("Expected error for empty filename")
 	}
 }
+
+func TestFileDialogExportExtension(t *testing.T) {
+	dialog := NewFileDialog(lipgloss.NewStyle())
+	dialog.Show(ModeExport, "song")
+
+	_, cmd := dialog.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Expected command to be returned")
+	}
+
+	confirmed, ok := cmd().(FileDialogConfirmed)
+	if !ok {
+		t.Fatal("Expected FileDialogConfirmed message")
+	}
+
+	if confirmed.Filename != "song.wav" {
+		t.Errorf("Expected Filename='song.wav', got '%s'", confirmed.Filename)
+	}
+}
@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+const (
+	// maxHistory bounds the number of undo steps kept
+	maxHistory = 256
+
+	// mergeWindow groups repeated edits of the same target into one undo step,
+	// e.g. holding an arrow key on a synth parameter
+	mergeWindow = time.Second
+)
+
+// Command is an undoable edit of the song
+type Command interface {
+	Do(t *TrackerModel)
+	Undo(t *TrackerModel)
+}
+
+// mergeable is implemented by commands that can absorb a following edit of the same target
+type mergeable interface {
+	merge(next Command) (Command, bool)
+}
+
+type historyEntry struct {
+	command Command
+	at      time.Time
+}
+
+// History holds the undo and redo stacks of the song edits
+type History struct {
+	done   []historyEntry
+	undone []historyEntry
+}
+
+func NewHistory() *History {
+	return &History{}
+}
+
+// Record adds an edit that has already been applied. Edits following the last one
+// on the same target within the merge window are grouped into one undo step.
+func (h *History) Record(command Command) {
+	if command == nil {
+		return
+	}
+
+	now := time.Now()
+	h.undone = nil
+
+	if last := len(h.done) - 1; last >= 0 && now.Sub(h.done[last].at) < mergeWindow {
+		if previous, ok := h.done[last].command.(mergeable); ok {
+			if merged, ok := previous.merge(command); ok {
+				h.done[last] = historyEntry{command: merged, at: now}
+				return
+			}
+		}
+	}
+
+	h.done = append(h.done, historyEntry{command: command, at: now})
+	if len(h.done) > maxHistory {
+		h.done = slices.Delete(h.done, 0, len(h.done)-maxHistory)
+	}
+}
+
+// Undo reverts the last edit, reporting false when there is nothing to undo
+func (h *History) Undo(t *TrackerModel) bool {
+	if len(h.done) == 0 {
+		return false
+	}
+
+	entry := h.done[len(h.done)-1]
+	h.done = h.done[:len(h.done)-1]
+	entry.command.Undo(t)
+	h.undone = append(h.undone, entry)
+	return true
+}
+
+// Redo applies the last undone edit again, reporting false when there is nothing to redo
+func (h *History) Redo(t *TrackerModel) bool {
+	if len(h.undone) == 0 {
+		return false
+	}
+
+	entry := h.undone[len(h.undone)-1]
+	h.undone = h.undone[:len(h.undone)-1]
+	entry.command.Do(t)
+	// A redone edit never merges with the next one
+	entry.at = time.Time{}
+	h.done = append(h.done, entry)
+	return true
+}
+
+// Clear drops all undo and redo steps, e.g. after loading a song
+func (h *History) Clear() {
+	h.done = nil
+	h.undone = nil
+}
+
+// rowEdit changes the cells of a track row
+type rowEdit struct {
+	track, row    int
+	before, after TrackRow
+}
+
+// RowEdit creates the command for a changed pattern cell, nil when nothing changed
+func RowEdit(track, row int, before, after TrackRow) Command {
+	if before == after {
+		return nil
+	}
+	return &rowEdit{track: track, row: row, before: before, after: after}
+}
+
+func (e *rowEdit) Do(t *TrackerModel) {
+	e.apply(t, e.after)
+}
+
+func (e *rowEdit) Undo(t *TrackerModel) {
+	e.apply(t, e.before)
+}
+
+// apply sets the row and moves the cursor to it so the change is visible
+func (e *rowEdit) apply(t *TrackerModel, row TrackRow) {
+	t.Tracks[e.track].Rows[e.row] = row
+	t.CursorTrack = e.track
+	t.CursorRow = e.row
+	t.scrollToCursor()
+}
+
+func (e *rowEdit) merge(next Command) (Command, bool) {
+	edit, ok := next.(*rowEdit)
+	if !ok || edit.track != e.track || edit.row != e.row {
+		return nil, false
+	}
+	return &rowEdit{track: e.track, row: e.row, before: e.before, after: edit.after}, true
+}
+
+// blockEdit changes the rows of a block of tracks
+type blockEdit struct {
+	track, row    int
+	before, after [][]TrackRow // rows per track starting at row
+}
+
+// BlockEdit creates the command for changed rows of several tracks, nil when nothing changed
+func BlockEdit(track, row int, before, after [][]TrackRow) Command {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return &blockEdit{track: track, row: row, before: before, after: after}
+}
+
+func (e *blockEdit) Do(t *TrackerModel) {
+	e.apply(t, e.after)
+}
+
+func (e *blockEdit) Undo(t *TrackerModel) {
+	e.apply(t, e.before)
+}
+
+func (e *blockEdit) apply(t *TrackerModel, rows [][]TrackRow) {
+	t.setBlock(e.track, e.row, rows)
+	t.ClearSelection()
+	t.CursorTrack = e.track
+	t.CursorRow = e.row
+	t.scrollToCursor()
+}
+
+// merge groups repeated edits of the same block, e.g. transposing a selection step by step
+func (e *blockEdit) merge(next Command) (Command, bool) {
+	edit, ok := next.(*blockEdit)
+	if !ok || edit.track != e.track || edit.row != e.row || !sameShape(edit.before, e.after) {
+		return nil, false
+	}
+	return &blockEdit{track: e.track, row: e.row, before: e.before, after: edit.after}, true
+}
+
+// sameShape reports whether two blocks span the same number of tracks and rows
+func sameShape(a, b [][]TrackRow) bool {
+	return len(a) == len(b) && (len(a) == 0 || len(a[0]) == len(b[0]))
+}
+
+// trackEdit changes the instrument and channel settings of a track, its rows are kept
+type trackEdit struct {
+	track         int
+	before, after Track
+}
+
+// TrackEdit creates the command for changed track settings, nil when nothing changed
+func TrackEdit(track int, before, after Track) Command {
+	before.Rows, after.Rows = nil, nil
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return &trackEdit{track: track, before: before, after: after}
+}
+
+func (e *trackEdit) Do(t *TrackerModel) {
+	e.apply(t, e.after)
+}
+
+func (e *trackEdit) Undo(t *TrackerModel) {
+	e.apply(t, e.before)
+}
+
+func (e *trackEdit) apply(t *TrackerModel, settings Track) {
+	track := &t.Tracks[e.track]
+	settings.number, settings.Rows = track.number, track.Rows
+	*track = settings
+	t.CursorTrack = e.track
+}
+
+func (e *trackEdit) merge(next Command) (Command, bool) {
+	edit, ok := next.(*trackEdit)
+	if !ok || edit.track != e.track {
+		return nil, false
+	}
+	return &trackEdit{track: e.track, before: e.before, after: edit.after}, true
+}
+
+// resizeEdit changes the number of rows of the pattern
+type resizeEdit struct {
+	beforeRows, afterRows int
+	before, after         [][]TrackRow // rows of all tracks
+}
+
+// ResizeEdit creates the command for a changed pattern length, nil when the length is the same
+func ResizeEdit(beforeRows, afterRows int, before, after [][]TrackRow) Command {
+	if beforeRows == afterRows {
+		return nil
+	}
+	return &resizeEdit{beforeRows: beforeRows, afterRows: afterRows, before: before, after: after}
+}
+
+func (e *resizeEdit) Do(t *TrackerModel) {
+	e.apply(t, e.afterRows, e.after)
+}
+
+func (e *resizeEdit) Undo(t *TrackerModel) {
+	e.apply(t, e.beforeRows, e.before)
+}
+
+func (e *resizeEdit) apply(t *TrackerModel, numRows int, rows [][]TrackRow) {
+	t.NumRows = numRows
+	for i := range rows {
+		t.Tracks[i].Rows = slices.Clone(rows[i])
+	}
+
+	// Keep the cursor, selection, loop and playback inside the pattern
+	t.ClearSelection()
+	t.LoopEndRow = min(t.LoopEndRow, numRows-1)
+	if t.PlaybackRow >= numRows {
+		t.PlaybackRow = 0
+	}
+	t.GoToRow(t.CursorRow)
+}
+
+// SongSettings holds the song wide settings outside of the patterns
+type SongSettings struct {
+	BPM      int
+	Master   audio.Master
+	Tuning   audio.Tuning
+	Buses    []audio.Bus
+	Channels []audio.Channel // channel settings of all tracks, as bus changes reroute them
+}
+
+// SongSettings returns the current song wide settings
+func (m *TrackerModel) SongSettings() SongSettings {
+	channels := make([]audio.Channel, len(m.Tracks))
+	for i, track := range m.Tracks {
+		channels[i] = track.Channel
+	}
+
+	return SongSettings{
+		BPM:      m.BPM,
+		Master:   m.Master,
+		Tuning:   m.Tuning,
+		Buses:    m.Buses,
+		Channels: channels,
+	}
+}
+
+// songEdit changes the song wide settings
+type songEdit struct {
+	before, after SongSettings
+}
+
+// SongEdit creates the command for changed song settings, nil when nothing changed
+func SongEdit(before, after SongSettings) Command {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return &songEdit{before: before, after: after}
+}
+
+func (e *songEdit) Do(t *TrackerModel) {
+	e.apply(t, e.after)
+}
+
+func (e *songEdit) Undo(t *TrackerModel) {
+	e.apply(t, e.before)
+}
+
+func (e *songEdit) apply(t *TrackerModel, settings SongSettings) {
+	t.BPM = settings.BPM
+	t.Master = settings.Master
+	t.Tuning = settings.Tuning
+	t.Buses = settings.Buses
+	for i, channel := range settings.Channels {
+		t.Tracks[i].Channel = channel
+	}
+}
+
+func (e *songEdit) merge(next Command) (Command, bool) {
+	edit, ok := next.(*songEdit)
+	if !ok {
+		return nil, false
+	}
+	return &songEdit{before: e.before, after: edit.after}, true
+}
+
+// commandGroup applies several commands as one undo step
+type commandGroup []Command
+
+// Group combines commands into one undo step, nil commands are skipped
+func Group(commands ...Command) Command {
+	var group commandGroup
+	for _, command := range commands {
+		if command != nil {
+			group = append(group, command)
+		}
+	}
+
+	switch len(group) {
+	case 0:
+		return nil
+	case 1:
+		return group[0]
+	}
+	return group
+}
+
+func (g commandGroup) Do(t *TrackerModel) {
+	for _, command := range g {
+		command.Do(t)
+	}
+}
+
+func (g commandGroup) Undo(t *TrackerModel) {
+	for i := len(g) - 1; i >= 0; i-- {
+		g[i].Undo(t)
+	}
+}
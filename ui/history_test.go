@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+func setNote(tracker *TrackerModel, history *History, row int, note audio.Note) {
+	tracker.CursorRow = row
+	before := tracker.Tracks[0].Rows[row]
+	history.Record(RowEdit(0, row, before, tracker.SetNote(note)))
+}
+
+func TestHistoryUndoRedo(t *testing.T) {
+	tracker := NewTracker(2, 16, 0, 20)
+	history := NewHistory()
+
+	c4 := audio.NewNote(audio.BaseC, audio.Octave4)
+	e4 := audio.NewNote(audio.BaseE, audio.Octave4)
+	setNote(tracker, history, 0, c4)
+	setNote(tracker, history, 1, e4)
+
+	if !history.Undo(tracker) {
+		t.Fatal("Expected an edit to undo")
+	}
+	if !audio.IsOff(tracker.Tracks[0].Rows[1].Note) {
+		t.Errorf("Expected row 1 to be empty after undo, got %s", tracker.Tracks[0].Rows[1].Note)
+	}
+	if tracker.Tracks[0].Rows[0].Note != c4 {
+		t.Errorf("Expected row 0 to keep C-4, got %s", tracker.Tracks[0].Rows[0].Note)
+	}
+
+	if !history.Redo(tracker) {
+		t.Fatal("Expected an edit to redo")
+	}
+	if tracker.Tracks[0].Rows[1].Note != e4 {
+		t.Errorf("Expected E-4 after redo, got %s", tracker.Tracks[0].Rows[1].Note)
+	}
+	if history.Redo(tracker) {
+		t.Error("Expected nothing left to redo")
+	}
+
+	// A new edit drops the undone ones
+	history.Undo(tracker)
+	setNote(tracker, history, 2, e4)
+	if history.Redo(tracker) {
+		t.Error("Expected redo stack to be cleared by a new edit")
+	}
+}
+
+func TestHistoryMergesEditsOfSameTarget(t *testing.T) {
+	tracker := NewTracker(2, 16, 0, 20)
+	history := NewHistory()
+
+	// Repeated transposes of one cell are one undo step
+	setNote(tracker, history, 0, audio.NewNote(audio.BaseC, audio.Octave4))
+	setNote(tracker, history, 0, audio.NewNote(audio.BaseC, audio.Octave5))
+	setNote(tracker, history, 0, audio.NewNote(audio.BaseC, audio.Octave6))
+
+	history.Undo(tracker)
+	if !audio.IsOff(tracker.Tracks[0].Rows[0].Note) {
+		t.Errorf("Expected merged edits to undo at once, got %s", tracker.Tracks[0].Rows[0].Note)
+	}
+	if history.Undo(tracker) {
+		t.Error("Expected a single undo step")
+	}
+
+	// Settings changes of a track merge, unchanged settings are not recorded
+	before := tracker.Tracks[1]
+	tracker.Tracks[1].Mixer.Balance = 0.5
+	history.Record(TrackEdit(1, before, tracker.Tracks[1]))
+	before = tracker.Tracks[1]
+	tracker.Tracks[1].Mixer.Balance = 0.6
+	history.Record(TrackEdit(1, before, tracker.Tracks[1]))
+	history.Record(TrackEdit(1, tracker.Tracks[1], tracker.Tracks[1]))
+
+	history.Undo(tracker)
+	if tracker.Tracks[1].Mixer.Balance != 0 {
+		t.Errorf("Expected Balance=0 after undo, got %v", tracker.Tracks[1].Mixer.Balance)
+	}
+	if len(tracker.Tracks[1].Rows) != 16 {
+		t.Errorf("Expected undo to keep the rows, got %d", len(tracker.Tracks[1].Rows))
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	tracker := NewTracker(1, maxHistory+10, 0, 20)
+	history := NewHistory()
+
+	for row := range maxHistory + 10 {
+		setNote(tracker, history, row, audio.NewNote(audio.BaseC, audio.Octave4))
+	}
+
+	undone := 0
+	for history.Undo(tracker) {
+		undone++
+	}
+	if undone != maxHistory {
+		t.Errorf("Expected %d undo steps, got %d", maxHistory, undone)
+	}
+}
@@ -0,0 +1,460 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// KeyContext is a set of key bindings active together. The bindings of a mode context
+// take precedence over the global ones.
+type KeyContext string
+
+const (
+	GlobalContext     KeyContext = "global"
+	TrackContext      KeyContext = "track" // pattern editor
+	OscillatorContext KeyContext = "oscillator"
+	EnvelopeContext   KeyContext = "envelope"
+	MixerContext      KeyContext = "mixer"
+	MasterContext     KeyContext = "master"
+	EffectsContext    KeyContext = "effects"
+	ConsoleContext    KeyContext = "console"
+	MacrosContext     KeyContext = "macros"
+	DrumContext       KeyContext = "drum"
+	SfxContext        KeyContext = "sfx"
+)
+
+// KeyContexts lists the key contexts
+var KeyContexts = []KeyContext{
+	GlobalContext, TrackContext, OscillatorContext, EnvelopeContext, MixerContext, MasterContext,
+	EffectsContext, ConsoleContext, MacrosContext, DrumContext, SfxContext,
+}
+
+// Action is a named command keys are bound to
+type Action string
+
+const (
+	ActionSave                Action = "save"
+	ActionLoad                Action = "load"
+	ActionExport              Action = "export"
+	ActionTuning              Action = "tuning"
+	ActionOscillator          Action = "oscillator"
+	ActionEnvelope            Action = "envelope"
+	ActionTrack               Action = "track"
+	ActionEffects             Action = "effects"
+	ActionMacros              Action = "macros"
+	ActionDrum                Action = "drum"
+	ActionSfx                 Action = "sfx"
+	ActionMaster              Action = "master"
+	ActionConsole             Action = "console"
+	ActionNextMode            Action = "next-mode"
+	ActionPreviousMode        Action = "previous-mode"
+	ActionOctaveUp            Action = "octave-up"
+	ActionOctaveDown          Action = "octave-down"
+	ActionVolumeDown          Action = "volume-down"
+	ActionVolumeUp            Action = "volume-up"
+	ActionPlay                Action = "play"
+	ActionLoop                Action = "loop"
+	ActionUndo                Action = "undo"
+	ActionRedo                Action = "redo"
+	ActionKeyboard            Action = "keyboard"
+	ActionKeyMode             Action = "key-mode"
+	ActionKeyRoot             Action = "key-root"
+	ActionQuit                Action = "quit"
+	ActionCommand             Action = "command"
+	ActionJump                Action = "jump"
+	ActionClear               Action = "clear"
+	ActionInsertRow           Action = "insert-row"
+	ActionInsertRowAll        Action = "insert-row-all"
+	ActionDeleteRow           Action = "delete-row"
+	ActionDeleteRowAll        Action = "delete-row-all"
+	ActionEditStepDown        Action = "edit-step-down"
+	ActionEditStepUp          Action = "edit-step-up"
+	ActionCopy                Action = "copy"
+	ActionCut                 Action = "cut"
+	ActionPaste               Action = "paste"
+	ActionPasteOverwrite      Action = "paste-overwrite"
+	ActionPasteMix            Action = "paste-mix"
+	ActionTransposeUp         Action = "transpose-up"
+	ActionTransposeDown       Action = "transpose-down"
+	ActionTransposeOctaveUp   Action = "transpose-octave-up"
+	ActionTransposeOctaveDown Action = "transpose-octave-down"
+	ActionTransposeKeyUp      Action = "transpose-key-up"
+	ActionTransposeKeyDown    Action = "transpose-key-down"
+	ActionReverse             Action = "reverse"
+	ActionInvert              Action = "invert"
+	ActionInterpolate         Action = "interpolate-volume"
+	ActionHumanize            Action = "humanize"
+
+	// Actions of the panels, the same action may be bound in several panel contexts
+	ActionPreviousField Action = "previous-field"
+	ActionNextField     Action = "next-field"
+	ActionDecrease      Action = "decrease"
+	ActionDecreaseMore  Action = "decrease-more"
+	ActionIncrease      Action = "increase"
+	ActionIncreaseMore  Action = "increase-more"
+	ActionPresets       Action = "presets"
+	ActionPreviousStrip Action = "previous-strip"
+	ActionNextStrip     Action = "next-strip"
+	ActionAdd           Action = "add"
+	ActionRemove        Action = "remove"
+	ActionToggle        Action = "toggle"
+	ActionPreviousMacro Action = "previous-macro"
+	ActionNextMacro     Action = "next-macro"
+	ActionPreviousStep  Action = "previous-step"
+	ActionNextStep      Action = "next-step"
+	ActionLoopPoint     Action = "loop-point"
+	ActionReleasePoint  Action = "release-point"
+	ActionGenerate      Action = "generate"
+	ActionRandomize     Action = "randomize"
+	ActionMutate        Action = "mutate"
+	ActionAudition      Action = "audition"
+	ActionUseInstrument Action = "use-instrument"
+	ActionExportSfx     Action = "export-sfx"
+)
+
+// ActionMsg carries an action bound in a panel context to the panel of the current mode
+type ActionMsg struct {
+	Action Action
+}
+
+// Binding binds keys to an action, the help text is shown in the footer
+type Binding struct {
+	Action Action
+	Keys   []string
+	Help   string
+}
+
+// fieldBindings move between the fields of a panel and adjust the selected one
+func fieldBindings() []Binding {
+	return []Binding{
+		{ActionPreviousField, []string{"up"}, "Previous Field"},
+		{ActionNextField, []string{"down"}, "Next Field"},
+		{ActionDecrease, []string{"left"}, "Decrease"},
+		{ActionDecreaseMore, []string{"shift+left"}, "Decrease More"},
+		{ActionIncrease, []string{"right"}, "Increase"},
+		{ActionIncreaseMore, []string{"shift+right"}, "Increase More"},
+	}
+}
+
+// KeymapProfile selects how the pattern editor is navigated
+type KeymapProfile string
+
+const (
+	DefaultProfile KeymapProfile = "default"
+	// VimProfile navigates the pattern in a vim style normal mode, notes are entered in insert mode
+	VimProfile KeymapProfile = "vim"
+)
+
+// KeymapProfiles lists the keymap profiles
+var KeymapProfiles = []KeymapProfile{DefaultProfile, VimProfile}
+
+// Keymap holds the key bindings per context, the navigation profile and the piano keyboard layout
+type Keymap struct {
+	Profile  KeymapProfile
+	Keyboard KeyboardLayout
+	bindings map[KeyContext][]Binding
+}
+
+// DefaultKeymap returns the built-in key bindings
+func DefaultKeymap() *Keymap {
+	return &Keymap{
+		Profile:  DefaultProfile,
+		Keyboard: QwertyLayout,
+		bindings: map[KeyContext][]Binding{
+			// The letter keys are piano keys in the pattern editor, so every global action
+			// also has a ctrl, alt or function key that works there
+			GlobalContext: {
+				{ActionOctaveUp, []string{"+"}, "Octave Up"},
+				{ActionOctaveDown, []string{"-", "_"}, "Octave Down"},
+				// for german keyboard layout we need to consider the alt+combo
+				{ActionVolumeDown, []string{"[", "alt+["}, "Volume Down"},
+				{ActionVolumeUp, []string{"]", "alt+]"}, "Volume Up"},
+				{ActionTrack, []string{"t", "f1"}, "Track"},
+				{ActionOscillator, []string{"o", "f2"}, "Oscillator"},
+				{ActionEnvelope, []string{"e", "f3"}, "Envelope"},
+				{ActionEffects, []string{"f", "f4"}, "Effects"},
+				{ActionMacros, []string{"i", "f5"}, "Macros"},
+				{ActionDrum, []string{"d", "f6"}, "Drum"},
+				{ActionSfx, []string{"x", "f7"}, "Sound FX"},
+				{ActionMaster, []string{"m", "f8"}, "Master"},
+				{ActionConsole, []string{"M", "f9"}, "Mixer"},
+				{ActionNextMode, []string{"tab"}, "Next Mode"},
+				{ActionPreviousMode, []string{"shift+tab"}, "Previous Mode"},
+				{ActionPlay, []string{"p"}, "Play/Pause"},
+				{ActionLoop, []string{"P"}, "Loop"},
+				{ActionSave, []string{"s", "ctrl+s"}, "Save"},
+				{ActionLoad, []string{"l", "ctrl+o"}, "Load"},
+				{ActionExport, []string{"ctrl+e"}, "Export"},
+				{ActionTuning, []string{"ctrl+t"}, "Tuning"},
+				{ActionUndo, []string{"ctrl+z"}, "Undo"},
+				{ActionRedo, []string{"ctrl+y"}, "Redo"},
+				{ActionKeyboard, []string{"ctrl+k"}, "Keyboard Layout"},
+				{ActionKeyMode, []string{"alt+k"}, "Key Mode"},
+				{ActionKeyRoot, []string{"alt+K"}, "Key Root"},
+				{ActionCommand, []string{":", "ctrl+p"}, "Command"},
+				{ActionQuit, []string{"q", "ctrl+c"}, "Quit"},
+			},
+			TrackContext: {
+				// The piano keys include p, so space plays in the pattern editor
+				{ActionPlay, []string{" "}, "Play/Pause"},
+				{ActionClear, []string{"delete"}, "Clear"},
+				{ActionInsertRow, []string{"insert"}, "Insert Row"},
+				{ActionDeleteRow, []string{"backspace"}, "Delete Row"},
+				{ActionInsertRowAll, []string{"alt+insert"}, "Insert Row in All Tracks"},
+				{ActionDeleteRowAll, []string{"alt+backspace"}, "Delete Row in All Tracks"},
+				{ActionEditStepDown, []string{"{"}, "Edit Step Down"},
+				{ActionEditStepUp, []string{"}"}, "Edit Step Up"},
+				{ActionJump, []string{"J"}, "Jump"},
+				// Block operations with Impulse Tracker keys, ctrl+c stays bound to quit
+				{ActionCopy, []string{"alt+c"}, "Copy"},
+				{ActionCut, []string{"alt+z"}, "Cut"},
+				{ActionPaste, []string{"alt+p"}, "Paste"},
+				{ActionPasteOverwrite, []string{"alt+o"}, "Overwrite"},
+				{ActionPasteMix, []string{"alt+m"}, "Mix"},
+				{ActionTransposeUp, []string{"alt+q"}, "Transpose Up"},
+				{ActionTransposeDown, []string{"alt+a"}, "Transpose Down"},
+				{ActionTransposeOctaveUp, []string{"alt+Q"}, "Transpose Octave Up"},
+				{ActionTransposeOctaveDown, []string{"alt+A"}, "Transpose Octave Down"},
+				{ActionTransposeKeyUp, []string{"alt+w"}, "Transpose in Key Up"},
+				{ActionTransposeKeyDown, []string{"alt+s"}, "Transpose in Key Down"},
+				{ActionReverse, []string{"alt+r"}, "Reverse"},
+				{ActionInvert, []string{"alt+i"}, "Invert"},
+				{ActionInterpolate, []string{"alt+j"}, "Interpolate Volume"},
+				{ActionHumanize, []string{"alt+h"}, "Humanize"},
+			},
+			OscillatorContext: {
+				{ActionPreviousField, []string{"up"}, "Previous Field"},
+				{ActionNextField, []string{"down"}, "Next Field"},
+				{ActionDecrease, []string{"left"}, "Decrease"},
+				{ActionIncrease, []string{"right"}, "Increase"},
+			},
+			EnvelopeContext: append(fieldBindings(),
+				Binding{ActionPresets, []string{"."}, "Presets"},
+			),
+			MixerContext:  fieldBindings(),
+			MasterContext: fieldBindings(),
+			DrumContext:   fieldBindings(),
+			EffectsContext: append(fieldBindings(),
+				Binding{ActionAdd, []string{"a", "insert"}, "Add Insert"},
+				Binding{ActionRemove, []string{"backspace"}, "Remove Insert"},
+			),
+			ConsoleContext: append(fieldBindings(),
+				Binding{ActionToggle, []string{"enter", " "}, "Toggle"},
+				Binding{ActionPreviousStrip, []string{","}, "Previous Strip"},
+				Binding{ActionNextStrip, []string{"."}, "Next Strip"},
+				Binding{ActionAdd, []string{"a"}, "Add Bus"},
+				Binding{ActionRemove, []string{"backspace"}, "Remove Bus"},
+			),
+			MacrosContext: {
+				{ActionPreviousMacro, []string{","}, "Previous Macro"},
+				{ActionNextMacro, []string{"."}, "Next Macro"},
+				{ActionPreviousStep, []string{"left"}, "Previous Step"},
+				{ActionNextStep, []string{"right"}, "Next Step"},
+				{ActionIncrease, []string{"up"}, "Increase"},
+				{ActionIncreaseMore, []string{"shift+up"}, "Increase More"},
+				{ActionDecrease, []string{"down"}, "Decrease"},
+				{ActionDecreaseMore, []string{"shift+down"}, "Decrease More"},
+				{ActionAdd, []string{"a", "insert"}, "Add Step"},
+				{ActionRemove, []string{"backspace"}, "Remove Step"},
+				{ActionLoopPoint, []string{"enter"}, "Loop"},
+				{ActionReleasePoint, []string{"r"}, "Release"},
+			},
+			SfxContext: append(fieldBindings(),
+				Binding{ActionGenerate, []string{"g"}, "New"},
+				Binding{ActionRandomize, []string{"r"}, "Random"},
+				Binding{ActionMutate, []string{"u"}, "Mutate"},
+				Binding{ActionAudition, []string{" "}, "Play"},
+				Binding{ActionUseInstrument, []string{"enter"}, "Use"},
+				Binding{ActionExportSfx, []string{"w"}, "WAV"},
+			),
+		},
+	}
+}
+
+// UseProfile switches the navigation profile, the vim profile adds u and ctrl+r for undo
+// and redo to the pattern editor bindings
+func (k *Keymap) UseProfile(profile KeymapProfile) error {
+	if !slices.Contains(KeymapProfiles, profile) {
+		return fmt.Errorf("unknown keymap profile %q", profile)
+	}
+
+	k.Profile = profile
+	if profile == VimProfile {
+		k.bindings[TrackContext] = append(k.bindings[TrackContext],
+			Binding{ActionUndo, []string{"u"}, "Undo"},
+			Binding{ActionRedo, []string{"ctrl+r"}, "Redo"},
+		)
+	}
+	return nil
+}
+
+// Action returns the action bound to a key in the context, falling back to the global bindings
+func (k *Keymap) Action(context KeyContext, key string) (Action, bool) {
+	for _, ctx := range []KeyContext{context, GlobalContext} {
+		for _, binding := range k.bindings[ctx] {
+			if slices.Contains(binding.Keys, key) {
+				return binding.Action, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Actions returns the actions of the given contexts, of all contexts without any.
+// The command line runs them by name.
+func (k *Keymap) Actions(contexts ...KeyContext) []Action {
+	if len(contexts) == 0 {
+		contexts = KeyContexts
+	}
+
+	var actions []Action
+	for _, context := range contexts {
+		for _, binding := range k.bindings[context] {
+			if !slices.Contains(actions, binding.Action) {
+				actions = append(actions, binding.Action)
+			}
+		}
+	}
+	return actions
+}
+
+// Bind replaces the keys of an action in a context. Only actions the context offers by
+// default can be bound, "space" may be used for the space bar.
+func (k *Keymap) Bind(context KeyContext, action Action, keys []string) error {
+	bindings, ok := k.bindings[context]
+	if !ok {
+		return fmt.Errorf("unknown key context %q", context)
+	}
+
+	idx := slices.IndexFunc(bindings, func(binding Binding) bool { return binding.Action == action })
+	if idx < 0 {
+		return fmt.Errorf("unknown action %q in %s keys", action, context)
+	}
+
+	normalized := make([]string, len(keys))
+	for i, key := range keys {
+		if key == "space" {
+			key = " "
+		}
+		normalized[i] = key
+	}
+	bindings[idx].Keys = normalized
+	return nil
+}
+
+// Validate reports keys bound to more than one action of a context, pattern editor keys
+// taken by the piano keys of the keyboard layout and global actions left without a key
+// in the pattern editor
+func (k *Keymap) Validate() error {
+	var errs []error
+	for _, context := range KeyContexts {
+		bound := map[string]Action{}
+		for _, binding := range k.bindings[context] {
+			for _, key := range binding.Keys {
+				if other, ok := bound[key]; ok {
+					errs = append(errs, fmt.Errorf("%s key %q is bound to %s and %s", context, formatKey(key), other, binding.Action))
+					continue
+				}
+				bound[key] = binding.Action
+
+				if context == TrackContext && k.patternShadowed(key) {
+					errs = append(errs, fmt.Errorf("track key %q of %s is a %s piano key", formatKey(key), binding.Action, k.Keyboard))
+				}
+			}
+		}
+	}
+
+	for _, binding := range k.bindings[GlobalContext] {
+		if !k.patternReachable(binding.Action) {
+			errs = append(errs, fmt.Errorf("%s has no key in the pattern editor besides the %s piano keys", binding.Action, k.Keyboard))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// patternShadowed reports whether the pattern editor takes a key before the key bindings.
+// In the vim profile the piano keys only play in insert mode, so only those normal mode
+// takes as well are lost.
+func (k *Keymap) patternShadowed(key string) bool {
+	_, piano := k.Keyboard.Semitone(key)
+	if k.Profile == VimProfile {
+		return piano && isVimKey(key)
+	}
+	return piano
+}
+
+// patternReachable reports whether a key runs the action in the pattern editor
+func (k *Keymap) patternReachable(action Action) bool {
+	for _, context := range KeyContexts {
+		for _, binding := range k.bindings[context] {
+			for _, key := range binding.Keys {
+				if bound, _ := k.Action(TrackContext, key); bound == action && !k.patternShadowed(key) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Help returns the footer help for the bindings of the context followed by the global ones.
+// Keys taken by shadowed, e.g. the piano keys of the pattern editor, are left out.
+func (k *Keymap) Help(context KeyContext, shadowed func(key string) bool) string {
+	contexts := []KeyContext{GlobalContext}
+	if context != GlobalContext {
+		contexts = []KeyContext{context, GlobalContext}
+	}
+
+	var help []string
+	seen := map[Action]bool{}
+	for _, ctx := range contexts {
+		for _, binding := range k.bindings[ctx] {
+			if seen[binding.Action] {
+				continue
+			}
+
+			idx := slices.IndexFunc(binding.Keys, func(key string) bool {
+				bound, _ := k.Action(context, key)
+				return bound == binding.Action && (shadowed == nil || !shadowed(key))
+			})
+			if idx < 0 {
+				continue
+			}
+			seen[binding.Action] = true
+			help = append(help, formatKey(binding.Keys[idx])+": "+binding.Help)
+		}
+	}
+	return strings.Join(help, " | ")
+}
+
+// formatKey formats a key for display, e.g. "ctrl+z" as "Ctrl+Z" and "M" as "Shift+M"
+func formatKey(key string) string {
+	switch key {
+	case " ":
+		return "Space"
+	case "+":
+		return key
+	}
+
+	parts := strings.Split(key, "+")
+	// Splitting a modified plus key leaves two empty parts
+	if strings.HasSuffix(key, "++") {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	for i, part := range parts {
+		runes := []rune(part)
+		switch {
+		case len(runes) == 1 && unicode.IsUpper(runes[0]):
+			parts[i] = "Shift+" + part
+		case len(runes) <= 1:
+			parts[i] = strings.ToUpper(part)
+		default:
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeymapHelp(t *testing.T) {
+	keymap := DefaultKeymap()
+
+	help := keymap.Help(GlobalContext, nil)
+	for _, want := range []string{"S: Save", "Shift+M: Mixer", "Ctrl+Z: Undo", "+: Octave Up"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Expected %q in help %q", want, help)
+		}
+	}
+
+	// Keys taken by the piano are left out, space plays in the pattern editor
+	piano := func(key string) bool {
+		_, ok := QwertyLayout.Semitone(key)
+		return ok
+	}
+	help = keymap.Help(TrackContext, piano)
+	if strings.Contains(help, " S: Save") || !strings.Contains(help, "Space: Play/Pause") {
+		t.Errorf("Expected piano keys left out of help %q", help)
+	}
+	for _, want := range []string{"Ctrl+S: Save", "Ctrl+O: Load", "Ctrl+C: Quit", "F2: Oscillator"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Expected %q in help %q", want, help)
+		}
+	}
+	if strings.Contains(help, "P: Play/Pause") {
+		t.Errorf("Expected play listed once in help %q", help)
+	}
+}
+
+func TestValidateKeymap(t *testing.T) {
+	// The defaults leave every action a key in the pattern editor with any layout and profile
+	for _, profile := range KeymapProfiles {
+		for _, layout := range KeyboardLayouts {
+			keymap := DefaultKeymap()
+			if err := keymap.UseProfile(profile); err != nil {
+				t.Fatal(err)
+			}
+			keymap.Keyboard = layout
+			if err := keymap.Validate(); err != nil {
+				t.Errorf("%s %s: %v", profile, layout, err)
+			}
+		}
+	}
+
+	keymap := DefaultKeymap()
+	if err := keymap.Bind(TrackContext, ActionClear, []string{"z"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := keymap.Validate(); err == nil || !strings.Contains(err.Error(), "piano key") {
+		t.Errorf("Expected a piano key conflict, got %v", err)
+	}
+
+	keymap = DefaultKeymap()
+	if err := keymap.Bind(GlobalContext, ActionSave, []string{"s"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := keymap.Validate(); err == nil || !strings.Contains(err.Error(), "save has no key") {
+		t.Errorf("Expected save without a pattern editor key, got %v", err)
+	}
+
+	// In vim normal mode the piano keys reach the bindings
+	if err := keymap.UseProfile(VimProfile); err != nil {
+		t.Fatal(err)
+	}
+	if err := keymap.Validate(); err != nil {
+		t.Errorf("Expected s to save in vim normal mode, got %v", err)
+	}
+}
+
+func TestPanelContexts(t *testing.T) {
+	keymap := DefaultKeymap()
+
+	if action, _ := keymap.Action(SfxContext, "g"); action != ActionGenerate {
+		t.Errorf("Expected g to generate a sound effect, got %q", action)
+	}
+	if action, _ := keymap.Action(SfxContext, "t"); action != ActionTrack {
+		t.Errorf("Expected the global bindings in the panels, got %q", action)
+	}
+	if _, ok := keymap.Action(OscillatorContext, "g"); ok {
+		t.Error("Expected g to be unbound outside of the sound effects")
+	}
+
+	help := keymap.Help(ConsoleContext, nil)
+	for _, want := range []string{"A: Add Bus", "Enter: Toggle", "F: Effects"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Expected %q in help %q", want, help)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+const (
+	maxMacroSteps     = 64
+	macroGraphHeight  = 8
+	macroVisibleSteps = 32
+	macroColumnWidth  = 3
+)
+
+var macroBarStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00e5ff"))
+
+// MacroModel is the bar graph editor for the macro tables of the current track's instrument
+type MacroModel struct {
+	Macros        audio.Macros
+	macroType     int // index into audio.MacroTypes
+	cursor        int
+	selectedStyle lipgloss.Style
+}
+
+type MacrosUpdated struct {
+	Macros audio.Macros
+}
+
+func NewMacroModel(selectedStyle lipgloss.Style, macros audio.Macros) *MacroModel {
+	return &MacroModel{
+		Macros:        macros,
+		selectedStyle: selectedStyle,
+	}
+}
+
+// SetMacros shows the macros of another instrument, keeping the cursor within the edited macro
+func (m *MacroModel) SetMacros(macros audio.Macros) {
+	m.Macros = macros
+	m.cursor = max(min(m.cursor, len(m.Macros.Get(audio.MacroTypes[m.macroType]).Values)-1), 0)
+}
+
+func (m *MacroModel) Init() tea.Cmd {
+	return nil
+}
+
+// macro returns a copy of the macro being edited whose values can be modified safely
+func (m *MacroModel) macro() audio.Macro {
+	macro := *m.Macros.Get(audio.MacroTypes[m.macroType])
+	macro.Values = slices.Clone(macro.Values)
+	return macro
+}
+
+func (m *MacroModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ActionMsg:
+		macroType := audio.MacroTypes[m.macroType]
+		macro := m.macro()
+
+		switch msg.Action {
+		case ActionPreviousMacro:
+			m.macroType = (m.macroType - 1 + len(audio.MacroTypes)) % len(audio.MacroTypes)
+			m.cursor = 0
+			return m, nil
+		case ActionNextMacro:
+			m.macroType = (m.macroType + 1) % len(audio.MacroTypes)
+			m.cursor = 0
+			return m, nil
+		case ActionPreviousStep:
+			m.cursor = max(m.cursor-1, 0)
+			return m, nil
+		case ActionNextStep:
+			m.cursor = min(m.cursor+1, max(len(macro.Values)-1, 0))
+			return m, nil
+		case ActionIncrease:
+			m.adjust(&macro, macroType, 1)
+		case ActionIncreaseMore:
+			m.adjust(&macro, macroType, 10)
+		case ActionDecrease:
+			m.adjust(&macro, macroType, -1)
+		case ActionDecreaseMore:
+			m.adjust(&macro, macroType, -10)
+		case ActionAdd:
+			// Add a step after the cursor, repeating the current value
+			if len(macro.Values) >= maxMacroSteps {
+				return m, nil
+			}
+			if !macro.Active() {
+				macro = audio.NewMacro()
+				macro.Values = []int{defaultMacroValue(macroType)}
+				m.cursor = 0
+				break
+			}
+			macro.Values = slices.Insert(macro.Values, m.cursor+1, macro.Values[m.cursor])
+			if macro.Loop > m.cursor {
+				macro.Loop++
+			}
+			if macro.Release > m.cursor {
+				macro.Release++
+			}
+			m.cursor++
+		case ActionRemove:
+			// Remove the step at the cursor
+			if !macro.Active() {
+				return m, nil
+			}
+			macro.Values = slices.Delete(macro.Values, m.cursor, m.cursor+1)
+			macro.Loop = removeMacroIndex(macro.Loop, m.cursor)
+			macro.Release = removeMacroIndex(macro.Release, m.cursor)
+			m.cursor = min(m.cursor, max(len(macro.Values)-1, 0))
+		case ActionLoopPoint:
+			// Toggle the loop point at the cursor
+			if !macro.Active() {
+				return m, nil
+			}
+			macro.Loop = togglePoint(macro.Loop, m.cursor)
+		case ActionReleasePoint:
+			// Toggle the release point at the cursor
+			if !macro.Active() {
+				return m, nil
+			}
+			macro.Release = togglePoint(macro.Release, m.cursor)
+		default:
+			return m, nil
+		}
+
+		*m.Macros.Get(macroType) = macro
+		macros := m.Macros
+		return m, func() tea.Msg { return MacrosUpdated{Macros: macros} }
+	}
+
+	return m, nil
+}
+
+// adjust changes the value at the cursor within the range of the macro type
+func (m *MacroModel) adjust(macro *audio.Macro, macroType audio.MacroType, steps int) {
+	if m.cursor >= len(macro.Values) {
+		return
+	}
+
+	lowest, highest := audio.MacroRange(macroType)
+	macro.Values[m.cursor] = min(max(macro.Values[m.cursor]+steps, lowest), highest)
+}
+
+// defaultMacroValue returns the value of the first step of a new macro
+func defaultMacroValue(macroType audio.MacroType) int {
+	switch macroType {
+	case audio.MacroVolume:
+		_, highest := audio.MacroRange(macroType)
+		return highest
+	case audio.MacroDuty:
+		return 2
+	}
+	return 0
+}
+
+// removeMacroIndex keeps a loop or release point on the same step after removing the step at idx
+func removeMacroIndex(point, idx int) int {
+	switch {
+	case point == idx:
+		return -1
+	case point > idx:
+		return point - 1
+	}
+	return point
+}
+
+// togglePoint sets a loop or release point to idx, or clears it when it is already there
+func togglePoint(point, idx int) int {
+	if point == idx {
+		return -1
+	}
+	return idx
+}
+
+func (m *MacroModel) View() string {
+	macroType := audio.MacroTypes[m.macroType]
+	macro := *m.Macros.Get(macroType)
+
+	view := strings.Builder{}
+
+	// Macro type tabs
+	view.WriteString("Macros:")
+	for i, t := range audio.MacroTypes {
+		view.WriteString(" ")
+		view.WriteString(renderFieldSelected(formatMacroType(t), i == m.macroType, m.selectedStyle))
+	}
+	view.WriteString("\n\n")
+
+	if !macro.Active() {
+		view.WriteString("(empty)\n")
+	} else {
+		start := max(0, m.cursor-macroVisibleSteps+1)
+		end := min(len(macro.Values), start+macroVisibleSteps)
+
+		lowest, highest := audio.MacroRange(macroType)
+		for _, line := range macroGraph(macro.Values[start:end], lowest, highest) {
+			view.WriteString(macroBarStyle.Render(line))
+			view.WriteString("\n")
+		}
+
+		var values, markers strings.Builder
+		for i := start; i < end; i++ {
+			values.WriteString(renderFieldSelected(fmt.Sprintf("%3d", macro.Values[i]), i == m.cursor, m.selectedStyle))
+
+			marker := ""
+			if i == macro.Loop {
+				marker += "L"
+			}
+			if i == macro.Release {
+				marker += "R"
+			}
+			markers.WriteString(fmt.Sprintf("%3s", marker))
+		}
+		view.WriteString(values.String() + "\n")
+		view.WriteString(markers.String() + "\n")
+	}
+
+	return strings.TrimSuffix(view.String(), "\n")
+}
+
+// macroGraph renders values as vertical bars, signed ranges grow up and down from the zero line
+func macroGraph(values []int, lowest, highest int) []string {
+	level := func(value int) int {
+		return (value - lowest) * macroGraphHeight / max(highest-lowest, 1)
+	}
+	zero := level(max(lowest, 0))
+
+	lines := make([]string, macroGraphHeight)
+	for row := range macroGraphHeight {
+		fromBottom := macroGraphHeight - 1 - row
+
+		var line strings.Builder
+		for _, value := range values {
+			top := level(value)
+			filled := (top > zero && fromBottom >= zero && fromBottom < top) ||
+				(top < zero && fromBottom >= top && fromBottom < zero)
+
+			// Zero values of signed ranges show as a thin line
+			if top == zero && fromBottom == zero && lowest < 0 {
+				line.WriteString(" ▁ ")
+				continue
+			}
+
+			if filled {
+				line.WriteString(strings.Repeat("█", macroColumnWidth-1) + " ")
+			} else {
+				line.WriteString(strings.Repeat(" ", macroColumnWidth))
+			}
+		}
+		lines[row] = line.String()
+	}
+
+	return lines
+}
+
+// formatMacroType formats a macro type as tab label
+func formatMacroType(macroType audio.MacroType) string {
+	name := string(macroType)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+func TestMacroCursorOnSetMacros(t *testing.T) {
+	long := audio.Macros{Volume: audio.Macro{Values: []int{15, 12, 9, 6, 3}, Loop: -1, Release: -1}}
+	macros := NewMacroModel(lipgloss.NewStyle(), long)
+	for range 4 {
+		macros.Update(ActionMsg{Action: ActionNextStep})
+	}
+	if macros.cursor != 4 {
+		t.Fatalf("Expected the cursor on the last step, got %d", macros.cursor)
+	}
+
+	// Switching to a shorter macro moves the cursor onto its last step, rendering leaves it
+	short := audio.Macros{Volume: audio.Macro{Values: []int{15, 8}, Loop: -1, Release: -1}}
+	macros.SetMacros(short)
+	if macros.cursor != 1 {
+		t.Errorf("Expected the cursor on step 1, got %d", macros.cursor)
+	}
+
+	macros.SetMacros(audio.Macros{})
+	macros.View()
+	if macros.cursor != 0 {
+		t.Errorf("Expected the cursor on step 0 of an empty macro, got %d", macros.cursor)
+	}
+}
@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// MasterEditField represents which master bus parameter is being edited
+type MasterEditField int
+
+const (
+	MasterDelayTime MasterEditField = iota
+	MasterDelayRows
+	MasterDelayFeedback
+	MasterDelayPingPong
+	MasterDelayMix
+	MasterCrushBits
+	MasterCrushDownsample
+	MasterReverbSize
+	MasterReverbDamping
+	MasterReverbMix
+	MasterHeadroom
+	MasterLimiterMode
+	MasterCeiling
+	MasterTuningReference
+	MasterTemperament
+	MasterTuningRoot
+	masterFieldCount
+)
+
+const (
+	maxDelayTimeMs = 2000
+	maxDelayRows   = 16
+	minHeadroomDb  = -24
+	minCeilingDb   = -12
+	minReference   = 380 // range of the reference pitch of A-4, moved along for other reference notes
+	maxReference   = 480
+)
+
+type MasterModel struct {
+	masterField   MasterEditField
+	Master        audio.Master
+	Tuning        audio.Tuning // song tuning, edited below the master bus settings
+	selectedStyle lipgloss.Style
+}
+
+type MasterUpdated struct {
+	Master audio.Master
+}
+
+// TuningUpdated is sent when the song tuning changes
+type TuningUpdated struct {
+	Tuning audio.Tuning
+}
+
+func NewMasterModel(selectedStyle lipgloss.Style, master audio.Master) *MasterModel {
+	return &MasterModel{
+		masterField:   MasterDelayTime,
+		Master:        master,
+		selectedStyle: selectedStyle,
+	}
+}
+
+func (m *MasterModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *MasterModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
+			m.masterField = (m.masterField - 1 + masterFieldCount) % masterFieldCount
+		case ActionNextField:
+			m.masterField = (m.masterField + 1) % masterFieldCount
+		case ActionDecrease:
+			m.adjust(-1)
+		case ActionDecreaseMore:
+			m.adjust(-10)
+		case ActionIncrease:
+			m.adjust(1)
+		case ActionIncreaseMore:
+			m.adjust(10)
+		default:
+			return m, nil
+		}
+
+		if m.masterField >= MasterTuningReference {
+			tuning := m.Tuning
+			return m, func() tea.Msg { return TuningUpdated{Tuning: tuning} }
+		}
+
+		master := m.Master
+		cmd = func() tea.Msg { return MasterUpdated{Master: master} }
+	}
+
+	return m, cmd
+}
+
+// adjust changes the current field by the given number of steps
+func (m *MasterModel) adjust(steps int) {
+	delay := &m.Master.Delay
+	crusher := &m.Master.Bitcrusher
+	reverb := &m.Master.Reverb
+	limiter := &m.Master.Limiter
+
+	switch m.masterField {
+	case MasterDelayTime:
+		delay.Time = clamp(delay.Time+float64(steps)*10, 0, maxDelayTimeMs)
+	case MasterDelayRows:
+		delay.Rows = min(max(delay.Rows+steps, 0), maxDelayRows)
+	case MasterDelayFeedback:
+		delay.Feedback = stepPercent(delay.Feedback, steps, 0.95)
+	case MasterDelayPingPong:
+		delay.PingPong = !delay.PingPong
+	case MasterDelayMix:
+		delay.Mix = stepPercent(delay.Mix, steps, 1)
+	case MasterCrushBits:
+		crusher.Bits = min(max(crusher.Bits+sign(steps), 0), 16)
+	case MasterCrushDownsample:
+		crusher.Downsample = min(max(crusher.Downsample+sign(steps), 0), maxDownsample)
+	case MasterReverbSize:
+		reverb.Size = stepPercent(reverb.Size, steps, 1)
+	case MasterReverbDamping:
+		reverb.Damping = stepPercent(reverb.Damping, steps, 1)
+	case MasterReverbMix:
+		reverb.Mix = stepPercent(reverb.Mix, steps, 1)
+	case MasterHeadroom:
+		limiter.Headroom = clamp(limiter.Headroom+float64(sign(steps)), minHeadroomDb, 0)
+	case MasterLimiterMode:
+		// Cycle limit -> soft clip -> off
+		switch {
+		case limiter.Bypass:
+			limiter.Bypass, limiter.SoftClip = false, steps < 0
+		case limiter.SoftClip:
+			limiter.SoftClip, limiter.Bypass = false, steps > 0
+		default:
+			limiter.SoftClip, limiter.Bypass = steps > 0, steps < 0
+		}
+	case MasterCeiling:
+		limiter.Ceiling = clamp(math.Round(limiter.Ceiling*10+float64(steps))/10, minCeilingDb, 0)
+	case MasterTuningReference:
+		lowest, highest := referenceRange(m.Tuning)
+		m.Tuning.Reference = clamp(math.Round((referencePitch(m.Tuning)+float64(steps))*100)/100, lowest, highest)
+	case MasterTemperament:
+		// The Scala temperament is only offered once a scale is loaded
+		temperaments := audio.Temperaments
+		if m.Tuning.Scale != nil {
+			temperaments = append(slices.Clone(temperaments), audio.ScalaTemperament)
+		}
+		idx := max(slices.Index(temperaments, m.Tuning.Temperament), 0)
+		m.Tuning.Temperament = temperaments[(idx+sign(steps)+len(temperaments))%len(temperaments)]
+	case MasterTuningRoot:
+		idx := max(slices.Index(tuningRoots, m.Tuning.Root), 0)
+		m.Tuning.Root = tuningRoots[(idx+sign(steps)+len(tuningRoots))%len(tuningRoots)]
+	}
+}
+
+var tuningRoots = []audio.Base{
+	audio.BaseC, audio.BaseCs, audio.BaseD, audio.BaseDs, audio.BaseE, audio.BaseF,
+	audio.BaseFs, audio.BaseG, audio.BaseGs, audio.BaseA, audio.BaseAs, audio.BaseB,
+}
+
+// referencePitch returns the reference frequency of the tuning, applying the default
+func referencePitch(tuning audio.Tuning) float64 {
+	if tuning.Reference <= 0 {
+		return audio.DefaultReference
+	}
+	return tuning.Reference
+}
+
+// referenceRange returns the range of the reference pitch, transposing the range of A-4 by
+// equal tempered semitones to the reference note of a keyboard mapping, e.g. 261.63Hz on C-4
+func referenceRange(tuning audio.Tuning) (lowest, highest float64) {
+	ratio := math.Pow(2, float64(tuning.ReferenceNote()-69)/audio.SemitonesPerOctave)
+	return minReference * ratio, maxReference * ratio
+}
+
+// formatTemperament formats a temperament for display
+func formatTemperament(tuning audio.Tuning) string {
+	switch tuning.Temperament {
+	case "":
+		return string(audio.EqualTemperament)
+	case audio.ScalaTemperament:
+		if tuning.Scale != nil && tuning.Scale.Description != "" {
+			return truncate(tuning.Scale.Description, 12)
+		}
+	}
+	return string(tuning.Temperament)
+}
+
+func (m *MasterModel) View() string {
+	delay := m.Master.Delay
+
+	view := strings.Builder{}
+	view.WriteString("Master:\n")
+
+	timeStr := fmt.Sprintf("Delay: %4dms", int(delay.Time))
+	if delay.Rows > 0 {
+		timeStr = "Delay: synced"
+	}
+	view.WriteString(renderFieldSelected(timeStr, m.masterField == MasterDelayTime, m.selectedStyle) + "\n")
+
+	rowsStr := "Sync: off"
+	if delay.Rows > 0 {
+		rowsStr = fmt.Sprintf("Sync: %d rows", delay.Rows)
+	}
+	view.WriteString(renderFieldSelected(rowsStr, m.masterField == MasterDelayRows, m.selectedStyle) + "\n")
+
+	view.WriteString(RenderKnobSelected("Feedback", delay.Feedback, m.masterField == MasterDelayFeedback, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Ping-Pong "+RenderOnOff(delay.PingPong), m.masterField == MasterDelayPingPong, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Mix", delay.Mix, m.masterField == MasterDelayMix, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Crush: "+formatBits(m.Master.Bitcrusher.Bits), m.masterField == MasterCrushBits, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Rate:  "+formatDownsample(m.Master.Bitcrusher.Downsample), m.masterField == MasterCrushDownsample, m.selectedStyle) + "\n")
+
+	reverb := m.Master.Reverb
+	view.WriteString(RenderKnobSelected("Room", reverb.Size, m.masterField == MasterReverbSize, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Damping", reverb.Damping, m.masterField == MasterReverbDamping, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Reverb", reverb.Mix, m.masterField == MasterReverbMix, m.selectedStyle) + "\n")
+
+	limiter := m.Master.Limiter
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Headroom: %3.0fdB", limiter.Headroom), m.masterField == MasterHeadroom, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Output: "+formatLimiterMode(limiter), m.masterField == MasterLimiterMode, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Ceiling: %5.1fdB", limiter.Ceiling), m.masterField == MasterCeiling, m.selectedStyle) + "\n")
+
+	root := m.Tuning.Root
+	if root == "" {
+		root = audio.BaseC
+	}
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Tuning: %.2fHz", referencePitch(m.Tuning)), m.masterField == MasterTuningReference, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Temper: "+formatTemperament(m.Tuning), m.masterField == MasterTemperament, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Root: "+string(root), m.masterField == MasterTuningRoot, m.selectedStyle))
+
+	return view.String()
+}
+
+// formatLimiterMode formats the output stage mode for display
+func formatLimiterMode(limiter audio.Limiter) string {
+	switch {
+	case limiter.Bypass:
+		return "off"
+	case limiter.SoftClip:
+		return "soft clip"
+	}
+	return "limiter"
+}
+
+// clamp limits value to the range [minValue, maxValue]
+func clamp(value, minValue, maxValue float64) float64 {
+	return min(max(value, minValue), maxValue)
+}
+
+// stepPercent moves a 0..maxValue value by steps of 1% and rounds away float drift
+func stepPercent(value float64, steps int, maxValue float64) float64 {
+	return clamp(math.Round(value*100+float64(steps))/100, 0, maxValue)
+}
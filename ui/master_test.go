@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+func TestMasterTuningReference(t *testing.T) {
+	master := NewMasterModel(lipgloss.NewStyle(), audio.Master{})
+	master.masterField = MasterTuningReference
+
+	// A keyboard mapping tuning C-4 keeps its reference instead of jumping into the A-4 range
+	master.Tuning = audio.Tuning{
+		Reference:   261.63,
+		Temperament: audio.ScalaTemperament,
+		Scale: &audio.Scale{
+			Cents:   []float64{100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200},
+			Mapping: audio.KeyboardMapping{Middle: 60, ReferenceNote: 60, Explicit: true},
+		},
+	}
+	master.adjust(1)
+	if master.Tuning.Reference != 262.63 {
+		t.Errorf("Expected reference 262.63Hz, got %f", master.Tuning.Reference)
+	}
+	if view := master.View(); !strings.Contains(view, "262.63Hz") {
+		t.Errorf("Expected the fractional reference in the view, got %q", view)
+	}
+
+	// Without a mapping the reference of A-4 stays in its range
+	master.Tuning = audio.Tuning{Reference: maxReference}
+	master.adjust(10)
+	if master.Tuning.Reference != maxReference {
+		t.Errorf("Expected reference clamped to %dHz, got %f", maxReference, master.Tuning.Reference)
+	}
+}
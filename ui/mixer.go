@@ -6,24 +6,52 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/tetrackt/tetrackt/audio"
 )
 
+// MixerEditField represents which mixer parameter is being edited
+type MixerEditField int
+
+const (
+	MixerBalance MixerEditField = iota
+	MixerCrossfade
+	MixerLevel1
+	MixerPan1
+	MixerLevel2
+	MixerPan2
+	MixerCrushBits
+	MixerCrushDownsample
+	MixerReverbSend
+	MixerPolyphony
+	MixerSteal
+	MixerVoiceMode
+	mixerFieldCount
+)
+
+const maxDownsample = 32
+
 type Mixer struct {
-	BalanceBar   Bar
-	Mixer        audio.Mixer
-	GlobalVolume float64 // Global output volume (0.0 to 1.0), set by main
+	BalanceBar    Bar
+	Mixer         audio.Mixer
+	Channel       audio.Channel
+	GlobalVolume  float64 // Global output volume (0.0 to 1.0), set by main
+	mixerField    MixerEditField
+	selectedStyle lipgloss.Style
 }
 
 type MixerUpdated struct {
-	Mixer audio.Mixer
+	Mixer   audio.Mixer
+	Channel audio.Channel
 }
 
-func NewMixer(balance float64) *Mixer {
+func NewMixer(selectedStyle lipgloss.Style, mixer audio.Mixer, channel audio.Channel) *Mixer {
 	return &Mixer{
-		Mixer:        audio.Mixer{Balance: balance},
-		BalanceBar:   NewBar(0, 1, balance, 10),
-		GlobalVolume: 1.0,
+		Mixer:         mixer,
+		Channel:       channel,
+		BalanceBar:    NewBar(0, 1, mixer.Balance, 10),
+		GlobalVolume:  1.0,
+		selectedStyle: selectedStyle,
 	}
 }
 
@@ -35,11 +63,47 @@ func (m *Mixer) View() string {
 	envView := strings.Builder{}
 	envView.WriteString("Mixer:\n")
 
-	v := m.Mixer.Balance
+	// The bar follows the mixer settings, which are replaced when the track changes
+	bar := m.BalanceBar
+	bar.Value = m.Mixer.Balance
+
+	// Show the actual oscillator gains of the crossfade curve next to the bar
+	gain1, gain2 := m.Mixer.Crossfade()
+	balance := fmt.Sprintf("%3d%% ", int(math.Round(gain1*100))) +
+		bar.View() +
+		fmt.Sprintf(" %3d%%", int(math.Round(gain2*100)))
+	envView.WriteString(renderFieldSelected(balance, m.mixerField == MixerBalance, m.selectedStyle))
+	envView.WriteString("\n")
+
+	curve := "linear"
+	if m.Mixer.EqualPower {
+		curve = "equal power"
+	}
+	envView.WriteString(renderFieldSelected("Curve: "+curve, m.mixerField == MixerCrossfade, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Level 1: "+formatGain(m.Mixer.Level1), m.mixerField == MixerLevel1, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Pan 1:   "+formatPan(m.Mixer.Pan1), m.mixerField == MixerPan1, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Level 2: "+formatGain(m.Mixer.Level2), m.mixerField == MixerLevel2, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Pan 2:   "+formatPan(m.Mixer.Pan2), m.mixerField == MixerPan2, m.selectedStyle))
+	envView.WriteString("\n")
+
+	crusher := m.Channel.Bitcrusher
+	envView.WriteString(renderFieldSelected("Crush: "+formatBits(crusher.Bits), m.mixerField == MixerCrushBits, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Rate:  "+formatDownsample(crusher.Downsample), m.mixerField == MixerCrushDownsample, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(RenderKnobSelected("Reverb", m.Channel.ReverbSend, m.mixerField == MixerReverbSend, m.selectedStyle))
+	envView.WriteString("\n")
 
-	fmt.Fprintf(&envView, "%3d%% ", int(math.Round((1-v)*100)))
-	envView.WriteString(m.BalanceBar.View())
-	fmt.Fprintf(&envView, " %3d%%", int(math.Round(v*100)))
+	voicing := m.Channel.Voicing
+	envView.WriteString(renderFieldSelected(fmt.Sprintf("Voices: %d", voicing.MaxVoices()), m.mixerField == MixerPolyphony, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Steal:  "+formatSteal(voicing.Steal), m.mixerField == MixerSteal, m.selectedStyle))
+	envView.WriteString("\n")
+	envView.WriteString(renderFieldSelected("Play:   "+formatVoiceMode(voicing.Mode), m.mixerField == MixerVoiceMode, m.selectedStyle))
 	envView.WriteString("\n")
 	envView.WriteString(fmt.Sprintf("Volume:  %3d%%", int(m.GlobalVolume*100)))
 
@@ -48,32 +112,114 @@ func (m *Mixer) View() string {
 
 func (m *Mixer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "left":
-			m.Mixer.Balance -= 0.01
-		case "shift+left":
-			m.Mixer.Balance -= 0.1
-		case "right":
-			m.Mixer.Balance += 0.01
-		case "shift+right":
-			m.Mixer.Balance += 0.1
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
+			m.mixerField = (m.mixerField - 1 + mixerFieldCount) % mixerFieldCount
+		case ActionNextField:
+			m.mixerField = (m.mixerField + 1) % mixerFieldCount
+		case ActionDecrease:
+			m.adjust(-1)
+		case ActionDecreaseMore:
+			m.adjust(-10)
+		case ActionIncrease:
+			m.adjust(1)
+		case ActionIncreaseMore:
+			m.adjust(10)
 		}
 	}
 
-	m.Mixer.Balance = math.Round(m.Mixer.Balance*100) / 100
-	if m.Mixer.Balance < 0 {
-		m.Mixer.Balance = 0
-	} else if m.Mixer.Balance > 1 {
-		m.Mixer.Balance = 1
-	}
-
-	m.BalanceBar.Value = m.Mixer.Balance
-
 	// TODO: Optimize to only send update when value changes
+	mixer, channel := m.Mixer, m.Channel
 	return m, func() tea.Msg {
 		return MixerUpdated{
-			Mixer: m.Mixer,
+			Mixer:   mixer,
+			Channel: channel,
 		}
 	}
 }
+
+// adjust changes the current field by the given number of steps
+func (m *Mixer) adjust(steps int) {
+	crusher := &m.Channel.Bitcrusher
+	voicing := &m.Channel.Voicing
+
+	switch m.mixerField {
+	case MixerBalance:
+		m.Mixer.Balance = stepPercent(m.Mixer.Balance, steps, 1)
+		m.BalanceBar.Value = m.Mixer.Balance
+	case MixerCrossfade:
+		m.Mixer.EqualPower = !m.Mixer.EqualPower
+	case MixerLevel1:
+		m.Mixer.Level1 = stepLevel(m.Mixer.Level1, steps)
+	case MixerPan1:
+		m.Mixer.Pan1 = stepPan(m.Mixer.Pan1, steps)
+	case MixerLevel2:
+		m.Mixer.Level2 = stepLevel(m.Mixer.Level2, steps)
+	case MixerPan2:
+		m.Mixer.Pan2 = stepPan(m.Mixer.Pan2, steps)
+	case MixerCrushBits:
+		crusher.Bits = min(max(crusher.Bits+sign(steps), 0), 16)
+	case MixerCrushDownsample:
+		crusher.Downsample = min(max(crusher.Downsample+sign(steps), 0), maxDownsample)
+	case MixerReverbSend:
+		m.Channel.ReverbSend = stepPercent(m.Channel.ReverbSend, steps, 1)
+	case MixerPolyphony:
+		voicing.Polyphony = min(max(voicing.MaxVoices()+sign(steps), 1), audio.MaxPolyphony)
+	case MixerSteal:
+		if voicing.Steal == audio.StealQuietest {
+			voicing.Steal = audio.StealOldest
+		} else {
+			voicing.Steal = audio.StealQuietest
+		}
+	case MixerVoiceMode:
+		if voicing.Mode == audio.Legato {
+			voicing.Mode = audio.Retrigger
+		} else {
+			voicing.Mode = audio.Legato
+		}
+	}
+}
+
+// formatSteal formats a voice stealing mode for display
+func formatSteal(steal audio.StealMode) string {
+	if steal == "" {
+		return string(audio.StealOldest)
+	}
+	return string(steal)
+}
+
+// formatVoiceMode formats a voice mode for display
+func formatVoiceMode(mode audio.VoiceMode) string {
+	if mode == "" {
+		return string(audio.Retrigger)
+	}
+	return string(mode)
+}
+
+// formatBits formats a bitcrusher bit depth for display
+func formatBits(bits int) string {
+	if bits <= 0 || bits >= 16 {
+		return "off"
+	}
+	return fmt.Sprintf("%d bit", bits)
+}
+
+// formatDownsample formats a bitcrusher rate reduction for display
+func formatDownsample(downsample int) string {
+	if downsample <= 1 {
+		return "off"
+	}
+	return fmt.Sprintf("1/%d", downsample)
+}
+
+// sign returns -1, 0 or 1 depending on the sign of value
+func sign(value int) int {
+	switch {
+	case value < 0:
+		return -1
+	case value > 0:
+		return 1
+	}
+	return 0
+}
@@ -64,15 +64,15 @@ func (m *OscillatorModel) View() string {
 func (m *OscillatorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "up":
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
 			// Move to previous oscillator field
 			m.editField = (m.editField - 1 + 2) % 2
-		case "down":
+		case ActionNextField:
 			// Move to next oscillator field
 			m.editField = (m.editField + 1) % 2
-		case "left":
+		case ActionDecrease:
 			switch m.editField {
 			case oscillatorType:
 				m.Oscillator.Type = cycle(m.oscillatorList, m.Oscillator.Type, -1)
@@ -84,7 +84,7 @@ func (m *OscillatorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			cmd = func() tea.Msg { return OscillatorUpdated{Oscillator: m.Oscillator} }
-		case "right":
+		case ActionIncrease:
 			switch m.editField {
 			case oscillatorType:
 				m.Oscillator.Type = cycle(m.oscillatorList, m.Oscillator.Type, 1)
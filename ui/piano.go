@@ -0,0 +1,61 @@
+package ui
+
+import "github.com/tetrackt/tetrackt/audio"
+
+// KeyboardLayout selects the keys used to play notes like a piano keyboard
+type KeyboardLayout string
+
+const (
+	// QwertyLayout, QwertzLayout and AzertyLayout map two octaves the way FT2 and Renoise do,
+	// the bottom letter row plays the octave setting and the top letter row the octave above
+	QwertyLayout KeyboardLayout = "qwerty"
+	QwertzLayout KeyboardLayout = "qwertz"
+	AzertyLayout KeyboardLayout = "azerty"
+	// DigitsLayout plays one octave on 1-7 with the sharps on the shifted digits
+	DigitsLayout KeyboardLayout = "digits"
+)
+
+// KeyboardLayouts lists the keyboard layouts in the order they are cycled through
+var KeyboardLayouts = []KeyboardLayout{QwertyLayout, QwertzLayout, AzertyLayout, DigitsLayout}
+
+// pianoKeys holds the keys of each layout in semitone order starting at C of the octave setting
+var pianoKeys = map[KeyboardLayout][][]string{
+	QwertyLayout: {
+		{"z", "s", "x", "d", "c", "v", "g", "b", "h", "n", "j", "m", ",", "l", ".", ";", "/"},
+		{"q", "2", "w", "3", "e", "r", "5", "t", "6", "y", "7", "u", "i", "9", "o", "0", "p"},
+	},
+	QwertzLayout: {
+		{"y", "s", "x", "d", "c", "v", "g", "b", "h", "n", "j", "m", ",", "l", ".", "ö", "-"},
+		{"q", "2", "w", "3", "e", "r", "5", "t", "6", "z", "7", "u", "i", "9", "o", "0", "p"},
+	},
+	AzertyLayout: {
+		{"w", "s", "x", "d", "c", "v", "g", "b", "h", "n", "j", ",", ";", "l", ":", "m", "!"},
+		{"a", "é", "z", "\"", "e", "r", "(", "t", "-", "y", "è", "u", "i", "ç", "o", "à", "p"},
+	},
+	DigitsLayout: {
+		{"1", "!", "2", "@", "3", "4", "$", "5", "%", "6", "^", "7"},
+	},
+}
+
+// digitsAliases are the shifted digits of the German keyboard layout for the digits layout
+var digitsAliases = map[string]string{
+	"\"": "@",
+	"&":  "^",
+}
+
+// Semitone returns the semitones above C of the octave setting played by a key,
+// reporting false when the key is not part of the layout
+func (l KeyboardLayout) Semitone(key string) (int, bool) {
+	if alias, ok := digitsAliases[key]; ok && l == DigitsLayout {
+		key = alias
+	}
+
+	for octave, keys := range pianoKeys[l] {
+		for semitone, pianoKey := range keys {
+			if pianoKey == key {
+				return octave*audio.SemitonesPerOctave + semitone, true
+			}
+		}
+	}
+	return 0, false
+}
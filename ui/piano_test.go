@@ -0,0 +1,35 @@
+package ui
+
+import "testing"
+
+func TestKeyboardLayouts(t *testing.T) {
+	tests := []struct {
+		layout KeyboardLayout
+		key    string
+		want   int
+	}{
+		{QwertyLayout, "z", 0},
+		{QwertyLayout, "m", 11},
+		{QwertyLayout, "q", 12},
+		{QwertyLayout, "3", 15},
+		{QwertyLayout, "p", 28},
+		{QwertzLayout, "y", 0},
+		{QwertzLayout, "z", 21},
+		{AzertyLayout, "w", 0},
+		{AzertyLayout, "a", 12},
+		{AzertyLayout, "é", 13},
+		{DigitsLayout, "!", 1},
+		{DigitsLayout, "&", 10}, // German keyboard layout
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.layout.Semitone(tt.key)
+		if !ok || got != tt.want {
+			t.Errorf("%s %q: expected semitone %d, got %d (%v)", tt.layout, tt.key, tt.want, got, ok)
+		}
+	}
+
+	if _, ok := QwertyLayout.Semitone("a"); ok {
+		t.Error("Expected a to play no note on qwerty")
+	}
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// SfxEditField represents which sound effect parameter is being edited
+type SfxEditField int
+
+const (
+	SfxCategory SfxEditField = iota
+	SfxWave
+	SfxDuty
+	SfxFrequency
+	SfxSlide
+	SfxJump
+	SfxJumpTime
+	SfxVibrato
+	SfxSpeed
+	SfxAttack
+	SfxSustain
+	SfxDecay
+	SfxPunch
+	sfxFieldCount
+)
+
+// sfxDuties are the pulse widths offered for square waves, 0 is the default 50%
+var sfxDuties = []float64{0, 0.125, 0.25, 0.75}
+
+// SfxModel is the sound effect generator creating a one shot instrument for the current track
+type SfxModel struct {
+	sfxField      SfxEditField
+	Instrument    audio.InstrumentType
+	Sfx           audio.Sfx
+	selectedStyle lipgloss.Style
+}
+
+type SfxUpdated struct {
+	Instrument audio.InstrumentType
+	Sfx        audio.Sfx
+	Play       bool // audition the new sound
+}
+
+// SfxExportRequested is sent to export the sound effect as one shot WAV file
+type SfxExportRequested struct {
+	Sfx audio.Sfx
+}
+
+func NewSfxModel(selectedStyle lipgloss.Style, instrument audio.InstrumentType, sfx audio.Sfx) *SfxModel {
+	return &SfxModel{
+		sfxField:      SfxCategory,
+		Instrument:    instrument,
+		Sfx:           sfx,
+		selectedStyle: selectedStyle,
+	}
+}
+
+func (m *SfxModel) Init() tea.Cmd {
+	return nil
+}
+
+// IsSfx reports whether the track plays the sound effect instead of the synth
+func (m *SfxModel) IsSfx() bool {
+	return m.Instrument == audio.SfxInstrument
+}
+
+func (m *SfxModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	play := false
+
+	switch msg := msg.(type) {
+	case ActionMsg:
+		switch msg.Action {
+		case ActionPreviousField:
+			m.sfxField = (m.sfxField - 1 + sfxFieldCount) % sfxFieldCount
+			return m, nil
+		case ActionNextField:
+			m.sfxField = (m.sfxField + 1) % sfxFieldCount
+			return m, nil
+		case ActionDecrease:
+			play = m.adjust(-1)
+		case ActionDecreaseMore:
+			play = m.adjust(-10)
+		case ActionIncrease:
+			play = m.adjust(1)
+		case ActionIncreaseMore:
+			play = m.adjust(10)
+		case ActionGenerate:
+			// Generate a new sound of the current category
+			m.Sfx = audio.NewSfx(m.category())
+			play = true
+		case ActionRandomize:
+			m.Sfx = audio.RandomSfx()
+			play = true
+		case ActionMutate:
+			m.Sfx = m.Sfx.Mutate()
+			play = true
+		case ActionUseInstrument:
+			// Use the sound as instrument of the track, or switch back to the synth
+			if m.IsSfx() {
+				m.Instrument = audio.SynthInstrument
+			} else {
+				m.Instrument = audio.SfxInstrument
+				if m.Sfx.Category == "" {
+					m.Sfx = audio.NewSfx(audio.SfxJump)
+				}
+			}
+		case ActionAudition:
+			play = true
+		case ActionExportSfx:
+			sfx := m.Sfx
+			return m, func() tea.Msg { return SfxExportRequested{Sfx: sfx} }
+		default:
+			return m, nil
+		}
+
+		instrument, sfx := m.Instrument, m.Sfx
+		return m, func() tea.Msg { return SfxUpdated{Instrument: instrument, Sfx: sfx, Play: play} }
+	}
+
+	return m, nil
+}
+
+// category returns the selected category, falling back to the first one
+func (m *SfxModel) category() audio.SfxCategory {
+	if m.Sfx.Category == "" {
+		return audio.SfxCategories[0]
+	}
+	return m.Sfx.Category
+}
+
+// adjust changes the current field by the given number of steps, reporting whether a new sound was generated
+func (m *SfxModel) adjust(steps int) bool {
+	sfx := &m.Sfx
+
+	switch m.sfxField {
+	case SfxCategory:
+		categories := audio.SfxCategories
+		idx := max(slices.Index(categories, sfx.Category), 0)
+		*sfx = audio.NewSfx(categories[(idx+sign(steps)+len(categories))%len(categories)])
+		return true
+	case SfxWave:
+		sfx.Wave = cycle(audio.SfxWaves, sfx.Wave, sign(steps))
+	case SfxDuty:
+		idx := max(slices.Index(sfxDuties, sfx.Duty), 0)
+		sfx.Duty = sfxDuties[(idx+sign(steps)+len(sfxDuties))%len(sfxDuties)]
+	case SfxFrequency:
+		sfx.Frequency = clamp(sfx.Frequency+float64(steps)*5, audio.MinSfxFrequency, audio.MaxSfxFrequency)
+	case SfxSlide:
+		sfx.Slide = clamp(sfx.Slide+float64(steps), -audio.MaxSfxSlide, audio.MaxSfxSlide)
+	case SfxJump:
+		sfx.Jump = clamp(sfx.Jump+float64(sign(steps)), -audio.MaxSfxJump, audio.MaxSfxJump)
+	case SfxJumpTime:
+		sfx.JumpTime = clamp(sfx.JumpTime+float64(steps)*5, 0, audio.MaxSfxTime)
+	case SfxVibrato:
+		sfx.Vibrato = clamp(sfx.Vibrato+float64(steps)*0.1, 0, audio.MaxSfxVibrato)
+	case SfxSpeed:
+		sfx.Speed = clamp(sfx.Speed+float64(steps)*0.5, 0, audio.MaxSfxSpeed)
+	case SfxAttack:
+		sfx.Attack = clamp(sfx.Attack+float64(steps)*5, 0, audio.MaxSfxTime)
+	case SfxSustain:
+		sfx.Sustain = clamp(sfx.Sustain+float64(steps)*5, 0, audio.MaxSfxTime)
+	case SfxDecay:
+		sfx.Decay = clamp(sfx.Decay+float64(steps)*5, 0, audio.MaxSfxTime)
+	case SfxPunch:
+		sfx.Punch = stepPercent(sfx.Punch, steps, 1)
+	}
+
+	return false
+}
+
+// formatDuty formats a pulse width for display
+func formatDuty(duty float64) string {
+	if duty <= 0 {
+		return "50%"
+	}
+	return fmt.Sprintf("%.1f%%", duty*100)
+}
+
+func (m *SfxModel) View() string {
+	sfx := m.Sfx
+
+	view := strings.Builder{}
+	title := "Sound FX:"
+	if m.IsSfx() {
+		title = "Sound FX (instrument):"
+	}
+	view.WriteString(title + "\n")
+
+	category := string(sfx.Category)
+	if category == "" {
+		category = "-"
+	}
+	wave := string(sfx.Wave)
+	if wave == "" {
+		wave = "-"
+	}
+
+	view.WriteString(renderFieldSelected("Sound: "+category, m.sfxField == SfxCategory, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Wave:  "+wave, m.sfxField == SfxWave, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected("Duty:  "+formatDuty(sfx.Duty), m.sfxField == SfxDuty, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Freq:  %4.0fHz", sfx.Frequency), m.sfxField == SfxFrequency, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Slide: %+3.0fst/s", sfx.Slide), m.sfxField == SfxSlide, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Jump:  %+3.0fst", sfx.Jump), m.sfxField == SfxJump, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("After: %4.0fms", sfx.JumpTime), m.sfxField == SfxJumpTime, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Vib:   %4.1fst", sfx.Vibrato), m.sfxField == SfxVibrato, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Speed: %4.1fHz", sfx.Speed), m.sfxField == SfxSpeed, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Att:   %4.0fms", sfx.Attack), m.sfxField == SfxAttack, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Sus:   %4.0fms", sfx.Sustain), m.sfxField == SfxSustain, m.selectedStyle) + "\n")
+	view.WriteString(renderFieldSelected(fmt.Sprintf("Dec:   %4.0fms", sfx.Decay), m.sfxField == SfxDecay, m.selectedStyle) + "\n")
+	view.WriteString(RenderKnobSelected("Punch", sfx.Punch, m.sfxField == SfxPunch, m.selectedStyle))
+
+	return view.String()
+}
@@ -2,7 +2,10 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -31,8 +34,55 @@ var (
 
 	cursorCellStyle = lipgloss.NewStyle().
 			Background(lipgloss.Color("#2a2a2a")).
-			Foreground(lipgloss.Color("#00e5ff")).
-			Padding(0, 1)
+			Foreground(lipgloss.Color("#00e5ff"))
+
+	cursorColumnStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#00e5ff")).
+				Foreground(lipgloss.Color("#000000")).
+				Bold(true)
+
+	selectedCellStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#1e3a5f")).
+				Padding(0, 1)
+)
+
+// CellColumn is the field of a pattern cell the cursor is on
+type CellColumn int
+
+const (
+	NoteColumn CellColumn = iota
+	VolumeHighColumn
+	VolumeLowColumn
+	EffectColumn
+	EffectHighColumn
+	EffectLowColumn
+	cellColumnCount
+)
+
+// columnSpans holds the character range of each column in a formatted cell
+var columnSpans = [cellColumnCount][2]int{
+	NoteColumn:       {0, 3},
+	VolumeHighColumn: {4, 5},
+	VolumeLowColumn:  {5, 6},
+	EffectColumn:     {7, 8},
+	EffectHighColumn: {8, 9},
+	EffectLowColumn:  {9, 10},
+}
+
+// maxEditStep bounds the number of rows the cursor moves down after an entry
+const maxEditStep = 16
+
+// rowsPerBeat is the number of rows in a beat, the tempo and the beat motions count in it
+const rowsPerBeat = 4
+
+const (
+	// DefaultBPM plays a row every 250ms
+	DefaultBPM = 60
+	MinBPM     = 20
+	MaxBPM     = 400
+
+	// MaxRows bounds the length of the pattern
+	MaxRows = 256
 )
 
 type Viewport struct {
@@ -42,17 +92,28 @@ type Viewport struct {
 
 // TrackerModel represents the state of the tracker pattern editor
 type TrackerModel struct {
-	Tracks      []Track
-	NumRows     int
-	NumTracks   int
-	CursorTrack int
-	CursorRow   int
-	IsPlaying   bool
-	LoopToRow   bool
-	LoopEndRow  int
-	PlaybackRow int
-	viewportRow int
-	Viewport    Viewport
+	Tracks       []Track
+	NumRows      int
+	NumTracks    int
+	CursorTrack  int
+	CursorRow    int
+	CursorColumn CellColumn
+	EditStep     int // rows the cursor moves down after entering a note or value
+	BPM          int // tempo in beats per minute, 0 plays at the default tempo
+	IsPlaying    bool
+	LoopToRow    bool
+	LoopEndRow   int
+	PlaybackRow  int
+	viewportRow  int
+	Viewport     Viewport
+	Master       audio.Master
+	Buses        []audio.Bus // submix buses, tracks route into them by number
+	Tuning       audio.Tuning
+
+	// block selection spans from the anchor to the cursor while selecting
+	selecting   bool
+	anchorTrack int
+	anchorRow   int
 }
 
 // Track represents a single track in the pattern
@@ -63,6 +124,11 @@ type Track struct {
 	Oscillator2 audio.Oscillator
 	Envelope2   audio.Envelope
 	Mixer       audio.Mixer
+	Macros      audio.Macros
+	Instrument  audio.InstrumentType
+	Drum        audio.Drum
+	Sfx         audio.Sfx
+	Channel     audio.Channel
 	Rows        []TrackRow
 }
 
@@ -88,11 +154,7 @@ func NewTracker(numTracks, numRows, viewportWidth, viewportHeight int) *TrackerM
 		}
 		// Initialize all rows with empty data
 		for j := range numRows {
-			tracks[i].Rows[j] = TrackRow{
-				Note:   audio.Off(),
-				Volume: 0,
-				Effect: "---",
-			}
+			tracks[i].Rows[j] = emptyRow()
 		}
 	}
 	return &TrackerModel{
@@ -104,8 +166,15 @@ func NewTracker(numTracks, numRows, viewportWidth, viewportHeight int) *TrackerM
 		LoopToRow:   false,
 		PlaybackRow: 0,
 		CursorRow:   0,
+		EditStep:    1,
+		BPM:         DefaultBPM,
 		viewportRow: 0,
 		Viewport:    Viewport{Width: viewportWidth, Height: viewportHeight},
+		Master: audio.Master{
+			Delay:   audio.Delay{Time: 250, Feedback: 0.3, Mix: 0},
+			Reverb:  audio.Reverb{Size: 0.5, Damping: 0.5, Mix: 0.3},
+			Limiter: audio.Limiter{Headroom: -6, Ceiling: -0.3},
+		},
 	}
 }
 
@@ -140,6 +209,7 @@ func (m *TrackerModel) View() string {
 	tracks.WriteString("\n")
 
 	endRow := min(m.viewportRow+m.visibleRows(), m.NumRows)
+	selection := m.Selection()
 
 	// Render visible rows
 	for row := m.viewportRow; row < endRow; row++ {
@@ -156,10 +226,12 @@ func (m *TrackerModel) View() string {
 		// Track cells
 		for trackIdx := 0; trackIdx < m.NumTracks; trackIdx++ {
 			trackRow := m.Tracks[trackIdx].Rows[row]
-			cellContent := fmt.Sprintf("%-3s %2s %3s", formatNote(trackRow.Note), formatVolume(trackRow.Volume), trackRow.Effect)
+			cellContent := formatCell(trackRow)
 
 			if row == m.CursorRow && trackIdx == m.CursorTrack {
-				tracks.WriteString(cursorCellStyle.Render(cellContent))
+				tracks.WriteString(m.renderCursorCell(cellContent))
+			} else if m.selecting && selection.Contains(trackIdx, row) {
+				tracks.WriteString(selectedCellStyle.Render(cellContent))
 			} else {
 				tracks.WriteString(cellStyle.Render(cellContent))
 			}
@@ -177,6 +249,11 @@ type TrackChanged struct {
 	Oscillator2 audio.Oscillator
 	Envelope2   audio.Envelope
 	Mixer       audio.Mixer
+	Macros      audio.Macros
+	Instrument  audio.InstrumentType
+	Drum        audio.Drum
+	Sfx         audio.Sfx
+	Channel     audio.Channel
 }
 
 func (m *TrackerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -186,44 +263,20 @@ func (m *TrackerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		keyStr := msg.String()
 
+		// Shift extends the block selection, moving without it drops the selection
+		if move, ok := strings.CutPrefix(keyStr, "shift+"); ok && movementKeys[move] {
+			m.startSelection()
+			keyStr = move
+		} else if movementKeys[keyStr] || keyStr == "esc" {
+			m.ClearSelection()
+		}
+
 		// Track mode key handling
 		switch keyStr {
 		case "left":
-			// Move cursor left (previous track)
-			if m.CursorTrack > 0 {
-				m.CursorTrack--
-
-				// TODO: Extract to common function
-				// TODO: solve sync problem between tracker and osc, env, mixer models
-				//m.oscillator1.Oscillator = m.pattern.tracks[m.cursorTrack].oscillator1
-				currentTrack := m.Tracks[m.CursorTrack]
-				cmd = func() tea.Msg {
-					return TrackChanged{
-						Oscillator1: currentTrack.Oscillator1,
-						Envelope1:   currentTrack.Envelope1,
-						Oscillator2: currentTrack.Oscillator2,
-						Envelope2:   currentTrack.Envelope2,
-						Mixer:       currentTrack.Mixer,
-					}
-				}
-			}
+			cmd = m.moveLeft()
 		case "right":
-			// Move cursor right (next track)
-			if m.CursorTrack < m.NumTracks-1 {
-				m.CursorTrack++
-				// TODO: solve sync problem between tracker and osc, env, mixer models
-				//m.oscillator1.Oscillator = m.pattern.tracks[m.cursorTrack].oscillator1
-				currentTrack := m.Tracks[m.CursorTrack]
-				cmd = func() tea.Msg {
-					return TrackChanged{
-						Oscillator1: currentTrack.Oscillator1,
-						Envelope1:   currentTrack.Envelope1,
-						Oscillator2: currentTrack.Oscillator2,
-						Envelope2:   currentTrack.Envelope2,
-						Mixer:       currentTrack.Mixer,
-					}
-				}
-			}
+			cmd = m.moveRight()
 		case "up":
 			// Move cursor up (previous row)
 			if m.CursorRow > 0 {
@@ -258,21 +311,103 @@ func (m *TrackerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// moveLeft moves the cursor to the previous column, from the note to the previous track.
+// Selections span whole cells, so extending one moves by track.
+func (m *TrackerModel) moveLeft() tea.Cmd {
+	if !m.selecting && m.CursorColumn > NoteColumn {
+		m.CursorColumn--
+		return nil
+	}
+	if m.CursorTrack == 0 {
+		return nil
+	}
+
+	cmd := m.SelectTrack(m.CursorTrack - 1)
+	if !m.selecting {
+		m.CursorColumn = cellColumnCount - 1
+	}
+	return cmd
+}
+
+// moveRight moves the cursor to the next column, from the effect to the next track
+func (m *TrackerModel) moveRight() tea.Cmd {
+	if !m.selecting && m.CursorColumn < cellColumnCount-1 {
+		m.CursorColumn++
+		return nil
+	}
+	if m.CursorTrack == m.NumTracks-1 {
+		return nil
+	}
+
+	cmd := m.SelectTrack(m.CursorTrack + 1)
+	if !m.selecting {
+		m.CursorColumn = NoteColumn
+	}
+	return cmd
+}
+
+// GoToRow moves the cursor to the row, clamped to the pattern
+func (m *TrackerModel) GoToRow(row int) {
+	m.CursorRow = min(max(row, 0), m.NumRows-1)
+	m.scrollToCursor()
+}
+
+// renderCursorCell renders the cell under the cursor with its column highlighted
+func (m *TrackerModel) renderCursorCell(content string) string {
+	span := columnSpans[m.CursorColumn]
+	return cursorCellStyle.Render(" "+content[:span[0]]) +
+		cursorColumnStyle.Render(content[span[0]:span[1]]) +
+		cursorCellStyle.Render(content[span[1]:]+" ")
+}
+
+// SelectTrack moves the cursor to the track and returns a command announcing the track change
+func (m *TrackerModel) SelectTrack(track int) tea.Cmd {
+	if track < 0 || track >= m.NumTracks {
+		return nil
+	}
+
+	m.CursorTrack = track
+
+	// TODO: solve sync problem between tracker and osc, env, mixer models
+	currentTrack := m.Tracks[m.CursorTrack]
+	return func() tea.Msg {
+		return TrackChanged{
+			Oscillator1: currentTrack.Oscillator1,
+			Envelope1:   currentTrack.Envelope1,
+			Oscillator2: currentTrack.Oscillator2,
+			Envelope2:   currentTrack.Envelope2,
+			Mixer:       currentTrack.Mixer,
+			Macros:      currentTrack.Macros,
+			Instrument:  currentTrack.Instrument,
+			Drum:        currentTrack.Drum,
+			Sfx:         currentTrack.Sfx,
+			Channel:     currentTrack.Channel,
+		}
+	}
+}
+
+// scrollToCursor moves the viewport the least amount needed to show the cursor row
+func (m *TrackerModel) scrollToCursor() {
+	if m.CursorRow < m.viewportRow {
+		m.viewportRow = m.CursorRow
+	}
+	if visibleRows := m.visibleRows(); m.CursorRow >= m.viewportRow+visibleRows {
+		m.viewportRow = max(m.CursorRow-visibleRows+1, 0)
+	}
+}
+
 func (m *TrackerModel) visibleRows() int {
 	chromeRows := 4 // header + separator + padding
 	return m.Viewport.Height - chromeRows
 }
 
 func formatNote(note audio.Note) string {
-	if note.Base == audio.BaseOff {
-		return "---"
-	}
-
-	if len(string(note.Base)) < 2 {
-		return fmt.Sprintf("%s-%d", note.Base, note.Octave)
-	}
+	return note.String()
+}
 
-	return fmt.Sprintf("%s%d", note.Base, note.Octave)
+// formatCell formats the note, volume and effect columns of a row
+func formatCell(row TrackRow) string {
+	return fmt.Sprintf("%-3s %2s %3s", formatNote(row.Note), formatVolume(row.Volume), row.Effect)
 }
 
 // formatVolume formats volume value for display
@@ -298,6 +433,192 @@ func (m *TrackerModel) SetNote(note audio.Note) TrackRow {
 	return *trackCell
 }
 
+// EnterValue types a digit or letter into the volume or effect column under the cursor and
+// moves on to the next digit of the field. Volumes are decimal up to 64, higher values are
+// clamped and tens digits above 6 rejected, effects are a
+// command letter or digit followed by two hex digits. Completing a field advances the
+// cursor by the edit step. Reports false when the character is not valid for the column,
+// leaving the row unchanged.
+func (m *TrackerModel) EnterValue(r rune) (TrackRow, bool) {
+	trackCell := &m.Tracks[m.CursorTrack].Rows[m.CursorRow]
+	r = unicode.ToUpper(r)
+
+	switch m.CursorColumn {
+	case VolumeHighColumn, VolumeLowColumn:
+		if r < '0' || r > '9' {
+			return *trackCell, false
+		}
+
+		digit := int(r - '0')
+		volume := trackCell.Volume/10*10 + digit
+		if m.CursorColumn == VolumeHighColumn {
+			if digit*10 > maxRowVolume {
+				return *trackCell, false
+			}
+			volume = digit*10 + trackCell.Volume%10
+		}
+		// A tens digit of 6 over ones above 4, e.g. on 59, gives the highest volume
+		trackCell.Volume = min(volume, maxRowVolume)
+	case EffectColumn, EffectHighColumn, EffectLowColumn:
+		valid := isHexDigit(r)
+		if m.CursorColumn == EffectColumn {
+			valid = isEffectCommand(r)
+		}
+		if !valid {
+			return *trackCell, false
+		}
+
+		// Typing into an empty effect starts with zero parameters
+		effect := []byte(trackCell.Effect)
+		if isEmptyEffect(trackCell.Effect) || len(effect) != 3 {
+			effect = []byte("-00")
+		}
+		effect[m.CursorColumn-EffectColumn] = byte(r)
+		trackCell.Effect = string(effect)
+	default:
+		return *trackCell, false
+	}
+
+	row := *trackCell
+	switch m.CursorColumn {
+	case VolumeLowColumn:
+		m.CursorColumn = VolumeHighColumn
+		m.Advance()
+	case EffectLowColumn:
+		m.CursorColumn = EffectColumn
+		m.Advance()
+	default:
+		m.CursorColumn++
+	}
+	return row, true
+}
+
+// Advance moves the cursor down by the edit step, stopping at the last row
+func (m *TrackerModel) Advance() {
+	m.CursorRow = min(m.CursorRow+m.EditStep, m.NumRows-1)
+	m.scrollToCursor()
+}
+
+// AdjustEditStep changes the edit step by delta, between 0 and 16 rows
+func (m *TrackerModel) AdjustEditStep(delta int) {
+	m.EditStep = min(max(m.EditStep+delta, 0), maxEditStep)
+}
+
+// RowDuration returns how long a row plays at the tempo of the song
+func (m *TrackerModel) RowDuration() time.Duration {
+	bpm := m.BPM
+	if bpm <= 0 {
+		bpm = DefaultBPM
+	}
+	return time.Minute / time.Duration(bpm*rowsPerBeat)
+}
+
+// SetBPM changes the tempo, tempos outside of 20 to 400 beats per minute are an error
+func (m *TrackerModel) SetBPM(bpm int) error {
+	if bpm < MinBPM || bpm > MaxBPM {
+		return fmt.Errorf("bpm must be between %d and %d", MinBPM, MaxBPM)
+	}
+	m.BPM = bpm
+	return nil
+}
+
+// Resize changes the number of rows of the pattern, rows past the end of a shorter
+// pattern are dropped and a longer one is filled with empty rows. Returns the command to undo it.
+func (m *TrackerModel) Resize(numRows int) (Command, error) {
+	if numRows < 1 || numRows > MaxRows {
+		return nil, fmt.Errorf("rows must be between 1 and %d", MaxRows)
+	}
+
+	before := make([][]TrackRow, len(m.Tracks))
+	after := make([][]TrackRow, len(m.Tracks))
+	for i, track := range m.Tracks {
+		before[i] = track.Rows
+		kept := min(numRows, len(track.Rows))
+		after[i] = slices.Concat(track.Rows[:kept], emptyRows(numRows-kept))
+	}
+
+	command := ResizeEdit(m.NumRows, numRows, before, after)
+	if command != nil {
+		command.Do(m)
+	}
+	return command, nil
+}
+
+// InsertRow inserts an empty row at the cursor in the current or all tracks, shifting the
+// rows below down, the last row is dropped. Returns the command to undo it.
+func (m *TrackerModel) InsertRow(allTracks bool) Command {
+	return m.shiftRows(allTracks, func(rows []TrackRow) []TrackRow {
+		return slices.Concat([]TrackRow{emptyRow()}, rows[:len(rows)-1])
+	})
+}
+
+// DeleteRow removes the row at the cursor in the current or all tracks, shifting the
+// rows below up, an empty row fills the end. Returns the command to undo it.
+func (m *TrackerModel) DeleteRow(allTracks bool) Command {
+	return m.DeleteRows(1, allTracks)
+}
+
+// DeleteRows removes count rows starting at the cursor like DeleteRow
+func (m *TrackerModel) DeleteRows(count int, allTracks bool) Command {
+	return m.shiftRows(allTracks, func(rows []TrackRow) []TrackRow {
+		count := min(count, len(rows))
+		return slices.Concat(rows[count:], emptyRows(count))
+	})
+}
+
+// shiftRows replaces the rows from the cursor to the end of the pattern with shifted ones
+func (m *TrackerModel) shiftRows(allTracks bool, shift func(rows []TrackRow) []TrackRow) Command {
+	block := Block{Track: m.CursorTrack, Row: m.CursorRow, Tracks: 1, Rows: m.NumRows - m.CursorRow}
+	if allTracks {
+		block.Track, block.Tracks = 0, m.NumTracks
+	}
+
+	before := m.block(block)
+	after := make([][]TrackRow, len(before))
+	for i, rows := range before {
+		after[i] = shift(rows)
+	}
+
+	m.setBlock(block.Track, block.Row, after)
+	return BlockEdit(block.Track, block.Row, before, after)
+}
+
+// ClearColumn empties the field under the cursor, the whole effect for the effect columns
+func (m *TrackerModel) ClearColumn() TrackRow {
+	trackCell := &m.Tracks[m.CursorTrack].Rows[m.CursorRow]
+	empty := emptyRow()
+
+	switch m.CursorColumn {
+	case NoteColumn:
+		trackCell.Note = empty.Note
+	case VolumeHighColumn, VolumeLowColumn:
+		trackCell.Volume = empty.Volume
+	default:
+		trackCell.Effect = empty.Effect
+	}
+
+	return *trackCell
+}
+
+// emptyRows returns count empty rows
+func emptyRows(count int) []TrackRow {
+	rows := make([]TrackRow, count)
+	for i := range rows {
+		rows[i] = emptyRow()
+	}
+	return rows
+}
+
+// isHexDigit reports whether r is an upper case hex digit
+func isHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'A' && r <= 'F')
+}
+
+// isEffectCommand reports whether r is a command digit or letter of the effect column
+func isEffectCommand(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z')
+}
+
 func (m *TrackerModel) GetNote() audio.Note {
 	trackCell := &m.Tracks[m.CursorTrack].Rows[m.CursorRow]
 	return trackCell.Note
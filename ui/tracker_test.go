@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCursorColumns(t *testing.T) {
+	tracker := NewTracker(2, 8, 0, 20)
+
+	// Moving right walks the columns of a cell before the next track
+	for range cellColumnCount {
+		tracker.Update(tea.KeyMsg{Type: tea.KeyRight})
+	}
+	if tracker.CursorTrack != 1 || tracker.CursorColumn != NoteColumn {
+		t.Errorf("Expected note column of track 1, got track %d column %d", tracker.CursorTrack, tracker.CursorColumn)
+	}
+
+	tracker.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if tracker.CursorTrack != 0 || tracker.CursorColumn != EffectLowColumn {
+		t.Errorf("Expected last column of track 0, got track %d column %d", tracker.CursorTrack, tracker.CursorColumn)
+	}
+}
+
+func TestEnterValue(t *testing.T) {
+	tracker := NewTracker(1, 8, 0, 20)
+
+	if _, ok := tracker.EnterValue('4'); ok {
+		t.Error("Expected digits to be rejected in the note column")
+	}
+
+	tracker.CursorColumn = VolumeHighColumn
+	tracker.EditStep = 0
+	tracker.EnterValue('4')
+	row, _ := tracker.EnterValue('8')
+	if row.Volume != 48 || tracker.CursorColumn != VolumeHighColumn {
+		t.Errorf("Expected volume 48 and the cursor back on the high digit, got %d in %d", row.Volume, tracker.CursorColumn)
+	}
+
+	// Tens digits above 6 are rejected
+	if _, ok := tracker.EnterValue('7'); ok {
+		t.Error("Expected volume 78 to be rejected")
+	}
+
+	// A tens digit of 6 over volume 59 and ones digits above 64 clamp to the highest volume
+	tracker.Tracks[0].Rows[0].Volume = 59
+	if row, ok := tracker.EnterValue('6'); !ok || row.Volume != maxRowVolume {
+		t.Errorf("Expected volume 59 to become %d, got %d (%v)", maxRowVolume, row.Volume, ok)
+	}
+	tracker.Tracks[0].Rows[0].Volume = 60
+	if row, ok := tracker.EnterValue('9'); !ok || row.Volume != maxRowVolume {
+		t.Errorf("Expected volume 69 to be clamped to %d, got %d (%v)", maxRowVolume, row.Volume, ok)
+	}
+	tracker.Tracks[0].Rows[0].Volume = 48
+
+	tracker.CursorColumn = EffectColumn
+	tracker.EnterValue('a')
+	if tracker.Tracks[0].Rows[0].Effect != "A00" {
+		t.Errorf("Expected effect A00, got %s", tracker.Tracks[0].Rows[0].Effect)
+	}
+	if _, ok := tracker.EnterValue('g'); ok {
+		t.Error("Expected non hex parameter to be rejected")
+	}
+	tracker.EnterValue('1')
+	row, _ = tracker.EnterValue('f')
+	if row.Effect != "A1F" {
+		t.Errorf("Expected effect A1F, got %s", row.Effect)
+	}
+
+	if row = tracker.ClearColumn(); row.Effect != "---" || row.Volume != 48 {
+		t.Errorf("Expected cleared effect only, got %+v", row)
+	}
+}
+
+func TestEditStepAndRowShifting(t *testing.T) {
+	tracker := NewTracker(2, 8, 0, 20)
+	tracker.AdjustEditStep(2)
+
+	// Completing a volume moves down by the edit step
+	tracker.CursorColumn = VolumeHighColumn
+	tracker.EnterValue('1')
+	tracker.EnterValue('6')
+	if tracker.CursorRow != 3 {
+		t.Errorf("Expected cursor on row 3, got %d", tracker.CursorRow)
+	}
+
+	tracker.CursorRow = 0
+	undo := tracker.InsertRow(false)
+	if got := tracker.Tracks[0].Rows[1].Volume; got != 16 {
+		t.Errorf("Expected the row moved down, got volume %d", got)
+	}
+
+	undo.Undo(tracker)
+	if got := tracker.Tracks[0].Rows[0].Volume; got != 16 {
+		t.Errorf("Expected undo to restore row 0, got volume %d", got)
+	}
+
+	// Deleting across all tracks pulls the rows below up
+	tracker.Tracks[1].Rows[1].Volume = 32
+	tracker.CursorTrack, tracker.CursorRow = 0, 0
+	tracker.DeleteRow(true)
+	if tracker.Tracks[0].Rows[0].Volume != 0 || tracker.Tracks[1].Rows[0].Volume != 32 {
+		t.Errorf("Expected rows of both tracks to move up, got %+v and %+v", tracker.Tracks[0].Rows[0], tracker.Tracks[1].Rows[0])
+	}
+	if got := tracker.Tracks[1].Rows[7]; got != emptyRow() {
+		t.Errorf("Expected an empty last row, got %+v", got)
+	}
+}
+
+func TestResizeAndTempo(t *testing.T) {
+	tracker := NewTracker(2, 8, 0, 20)
+	tracker.Tracks[1].Rows[7].Volume = 32
+	tracker.CursorRow = 7
+
+	undo, err := tracker.Resize(4)
+	if err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if tracker.NumRows != 4 || len(tracker.Tracks[1].Rows) != 4 || tracker.CursorRow != 3 {
+		t.Errorf("Expected 4 rows with the cursor on row 3, got %d rows and cursor %d", tracker.NumRows, tracker.CursorRow)
+	}
+
+	undo.Undo(tracker)
+	if tracker.NumRows != 8 || tracker.Tracks[1].Rows[7].Volume != 32 {
+		t.Errorf("Expected undo to restore the dropped rows, got %d rows", tracker.NumRows)
+	}
+
+	// A selection past the end of the shorter pattern is dropped
+	selectRows(tracker, 5, 7)
+	if _, err := tracker.Resize(4); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if tracker.HasSelection() {
+		t.Error("Expected the selection to be cleared")
+	}
+	if clipboard := tracker.Copy(); len(clipboard.Tracks[0]) != 1 {
+		t.Errorf("Expected the cursor cell copied, got %d rows", len(clipboard.Tracks[0]))
+	}
+
+	if _, err := tracker.Resize(MaxRows + 1); err == nil {
+		t.Error("Expected an error for too many rows")
+	}
+
+	if got := tracker.RowDuration(); got != 250*time.Millisecond {
+		t.Errorf("Expected 250ms rows at the default tempo, got %v", got)
+	}
+	if err := tracker.SetBPM(120); err != nil || tracker.RowDuration() != 125*time.Millisecond {
+		t.Errorf("Expected 125ms rows at 120 bpm, got %v (%v)", tracker.RowDuration(), err)
+	}
+	if err := tracker.SetBPM(MaxBPM + 1); err == nil {
+		t.Error("Expected an error for a tempo out of range")
+	}
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"math/rand/v2"
+	"slices"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// maxRowVolume is the loudest row volume, the empty volume plays at full volume
+const maxRowVolume = 64
+
+// transformSelection changes the rows of each track of the selection in place
+// and returns the command to undo it. The selection is kept for further transforms.
+func (m *TrackerModel) transformSelection(transform func(rows []TrackRow)) Command {
+	block := m.Selection()
+	before := m.block(block)
+	after := m.block(block)
+	for _, rows := range after {
+		transform(rows)
+	}
+
+	m.setBlock(block.Track, block.Row, after)
+	return BlockEdit(block.Track, block.Row, before, after)
+}
+
+// transposeNotes moves every note with transpose, notes it cannot move are kept
+func transposeNotes(rows []TrackRow, transpose func(audio.Note) (audio.Note, bool)) {
+	for i := range rows {
+		if note, ok := transpose(rows[i].Note); ok {
+			rows[i].Note = note
+		}
+	}
+}
+
+// Transpose moves the selected notes by semitones
+func (m *TrackerModel) Transpose(semitones int) Command {
+	return m.transformSelection(func(rows []TrackRow) {
+		transposeNotes(rows, func(note audio.Note) (audio.Note, bool) {
+			return note.Transpose(semitones)
+		})
+	})
+}
+
+// TransposeInKey moves the selected notes by degrees of the scale of the key
+func (m *TrackerModel) TransposeInKey(key audio.Key, degrees int) Command {
+	return m.transformSelection(func(rows []TrackRow) {
+		transposeNotes(rows, func(note audio.Note) (audio.Note, bool) {
+			return note.TransposeInKey(key, degrees)
+		})
+	})
+}
+
+// Reverse plays the selected rows of each track backwards (retrograde)
+func (m *TrackerModel) Reverse() Command {
+	return m.transformSelection(slices.Reverse[[]TrackRow])
+}
+
+// Invert mirrors the selected notes of each track around its first note
+func (m *TrackerModel) Invert() Command {
+	return m.transformSelection(func(rows []TrackRow) {
+		pivot := slices.IndexFunc(rows, func(row TrackRow) bool { return !audio.IsOff(row.Note) })
+		if pivot < 0 {
+			return
+		}
+
+		pivotNote := rows[pivot].Note
+		transposeNotes(rows, func(note audio.Note) (audio.Note, bool) {
+			return note.Transpose(2 * (pivotNote.MIDI() - note.MIDI()))
+		})
+	})
+}
+
+// InterpolateVolume fades the volume of the selected rows from the first to the last row,
+// the empty volume counts as full volume
+func (m *TrackerModel) InterpolateVolume() Command {
+	return m.transformSelection(func(rows []TrackRow) {
+		last := len(rows) - 1
+		if last < 1 {
+			return
+		}
+
+		from, to := playedVolume(rows[0].Volume), playedVolume(rows[last].Volume)
+		for i := range rows {
+			rows[i].Volume = from + (to-from)*i/last
+		}
+	})
+}
+
+// HumanizeVolume randomizes the volume of the selected notes by up to spread around their
+// volume, the empty volume counts as full volume
+func (m *TrackerModel) HumanizeVolume(spread int) Command {
+	return m.transformSelection(func(rows []TrackRow) {
+		for i := range rows {
+			if audio.IsOff(rows[i].Note) {
+				continue
+			}
+
+			volume := playedVolume(rows[i].Volume)
+			rows[i].Volume = min(max(volume+rand.IntN(2*spread+1)-spread, 1), maxRowVolume)
+		}
+	})
+}
+
+// playedVolume returns the volume a row plays at, the empty volume plays at full volume
+func playedVolume(volume int) int {
+	if volume == 0 {
+		return maxRowVolume
+	}
+	return volume
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+func selectRows(tracker *TrackerModel, from, to int) {
+	tracker.ClearSelection()
+	tracker.CursorRow = from
+	tracker.startSelection()
+	tracker.CursorRow = to
+}
+
+func TestTransforms(t *testing.T) {
+	c4 := audio.NewNote(audio.BaseC, audio.Octave4)
+	e4 := audio.NewNote(audio.BaseE, audio.Octave4)
+	g4 := audio.NewNote(audio.BaseG, audio.Octave4)
+
+	tracker := NewTracker(1, 8, 0, 20)
+	tracker.Tracks[0].Rows[0] = TrackRow{Note: c4, Volume: 64, Effect: "---"}
+	tracker.Tracks[0].Rows[1] = TrackRow{Note: e4, Volume: 0, Effect: "---"}
+	tracker.Tracks[0].Rows[2] = TrackRow{Note: g4, Volume: 16, Effect: "---"}
+	selectRows(tracker, 0, 2)
+
+	notes := func() []audio.Note {
+		rows := tracker.Tracks[0].Rows
+		return []audio.Note{rows[0].Note, rows[1].Note, rows[2].Note}
+	}
+
+	// C major: C E G -> D F A
+	tracker.TransposeInKey(audio.Key{}, 1)
+	if got := notes(); got[0].String() != "D-4" || got[1].String() != "F-4" || got[2].String() != "A-4" {
+		t.Errorf("Expected D-4 F-4 A-4, got %v", got)
+	}
+
+	undo := tracker.Transpose(-2)
+	undo.Undo(tracker)
+	undo.Do(tracker)
+	if got := notes(); got[0].String() != "C-4" || got[1].String() != "D#4" || got[2].String() != "G-4" {
+		t.Errorf("Expected C-4 D#4 G-4, got %v", got)
+	}
+
+	// Inversion around the first note: C D# G -> C A-3 F-3
+	selectRows(tracker, 0, 2)
+	tracker.Invert()
+	if got := notes(); got[0].String() != "C-4" || got[1].String() != "A-3" || got[2].String() != "F-3" {
+		t.Errorf("Expected C-4 A-3 F-3, got %v", got)
+	}
+
+	tracker.Reverse()
+	if got := notes(); got[0].String() != "F-3" || got[2].String() != "C-4" {
+		t.Errorf("Expected reversed notes, got %v", got)
+	}
+
+	// Reversed volumes are 16 .. 64
+	tracker.InterpolateVolume()
+	if got := tracker.Tracks[0].Rows[1].Volume; got != 40 {
+		t.Errorf("Expected interpolated volume 40, got %d", got)
+	}
+
+	tracker.HumanizeVolume(8)
+	for row := range 3 {
+		if volume := tracker.Tracks[0].Rows[row].Volume; volume < 8 || volume > 64 {
+			t.Errorf("Expected humanized volume in range, got %d", volume)
+		}
+	}
+}
+
+func TestInterpolateFromEmptyVolume(t *testing.T) {
+	tracker := NewTracker(1, 8, 0, 20)
+	tracker.Tracks[0].Rows[4].Volume = 32
+	selectRows(tracker, 0, 4)
+
+	// The empty volume of the first row plays at full volume, so the rows fade down
+	tracker.InterpolateVolume()
+	want := []int{64, 56, 48, 40, 32}
+	for row, volume := range want {
+		if got := tracker.Tracks[0].Rows[row].Volume; got != volume {
+			t.Errorf("Row %d: expected volume %d, got %d", row, volume, got)
+		}
+	}
+}
+
+func TestTransposeInKey(t *testing.T) {
+	a := audio.Key{Root: audio.BaseA, Mode: audio.MinorPentatonicMode}
+
+	tests := []struct {
+		note    string
+		degrees int
+		want    string
+	}{
+		{"A-3", 1, "C-4"},
+		{"G-3", 1, "A-3"},
+		{"A-3", -1, "G-3"},
+		{"A-3", 5, "A-4"},
+		{"A#3", 1, "C#4"}, // outside the scale, keeps its offset
+	}
+
+	for _, tt := range tests {
+		note, _ := audio.ParseNote(tt.note)
+		got, ok := note.TransposeInKey(a, tt.degrees)
+		if !ok || got.String() != tt.want {
+			t.Errorf("%s by %d: expected %s, got %s", tt.note, tt.degrees, tt.want, got)
+		}
+	}
+}
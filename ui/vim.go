@@ -0,0 +1,251 @@
+package ui
+
+import (
+	"slices"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+// vimMark is a cursor position saved with m{a-z}
+type vimMark struct {
+	track, row int
+}
+
+// Vim interprets vim style normal mode keys in the pattern editor. In insert mode
+// the keys are left to the note and value entry until esc returns to normal mode.
+type Vim struct {
+	Insert bool
+
+	count        string // count digits typed so far
+	pending      string // operator or prefix waiting for its second key, e.g. the first d of dd
+	pendingCount string
+	marks        map[string]vimMark
+}
+
+func NewVim() *Vim {
+	return &Vim{marks: map[string]vimMark{}}
+}
+
+// vimKeys are the keys normal mode takes, besides the digits of counts
+var vimKeys = []string{"h", "j", "k", "l", "0", "$", "w", "b", "{", "}", "G", "x", "p", "P", "i", "d", "y", "g", "m", "'", "`", "[", "]", "esc"}
+
+// Takes reports whether the current mode takes a key away from the key bindings
+func (v *Vim) Takes(key string) bool {
+	if v.Insert {
+		return key == "esc"
+	}
+	return isVimKey(key)
+}
+
+// isVimKey reports whether normal mode takes a key
+func isVimKey(key string) bool {
+	return slices.Contains(vimKeys, key) || (len(key) == 1 && key[0] >= '1' && key[0] <= '9')
+}
+
+// Mode returns the name of the current vim mode for display
+func (v *Vim) Mode() string {
+	if v.Insert {
+		return "INSERT"
+	}
+	return "NORMAL"
+}
+
+// Handle interprets a key in normal mode, yanked rows go to the clipboard. It returns the
+// command to undo an edit, the command announcing a track change and whether the key was
+// taken. Keys without a meaning in vim are left to the global key bindings.
+func (v *Vim) Handle(key string, t *TrackerModel, clipboard *Clipboard) (Command, tea.Cmd, bool) {
+	if v.Insert {
+		if key != "esc" {
+			return nil, nil, false
+		}
+		v.Insert = false
+		t.ClearSelection()
+		return nil, nil, true
+	}
+
+	if v.pending != "" {
+		prefix := v.pending
+		v.pending, v.count = "", v.pendingCount
+		command, cmd := v.complete(prefix, key, t, clipboard)
+		return command, cmd, true
+	}
+
+	// Counts start at 1-9, a leading 0 is a motion
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || v.count != "") {
+		v.count += key
+		return nil, nil, true
+	}
+
+	// Counts are clamped to the pattern, so huge counts cannot stall the motions
+	limit := t.NumRows
+	if key == "h" || key == "l" || key == "left" || key == "right" {
+		limit = t.NumTracks * int(cellColumnCount)
+	}
+	explicit := v.count != ""
+	count := v.takeCount(limit)
+
+	var command Command
+	var cmd tea.Cmd
+
+	switch key {
+	case "h", "left":
+		for range count {
+			cmd = lastCmd(cmd, t.moveLeft())
+		}
+	case "l", "right":
+		for range count {
+			cmd = lastCmd(cmd, t.moveRight())
+		}
+	case "j", "down":
+		t.GoToRow(t.CursorRow + count)
+	case "k", "up":
+		t.GoToRow(t.CursorRow - count)
+	case "0":
+		t.CursorColumn = NoteColumn
+	case "$":
+		t.CursorColumn = cellColumnCount - 1
+	case "w":
+		t.GoToRow(t.filledRow(count))
+	case "b":
+		t.GoToRow(t.filledRow(-count))
+	case "}":
+		t.GoToRow((t.CursorRow/rowsPerBeat + count) * rowsPerBeat)
+	case "{":
+		t.GoToRow((t.CursorRow+rowsPerBeat-1)/rowsPerBeat*rowsPerBeat - count*rowsPerBeat)
+	case "G":
+		row := t.NumRows - 1
+		if explicit {
+			row = count
+		}
+		t.GoToRow(row)
+	case "x":
+		row := t.Tracks[t.CursorTrack].Rows[t.CursorRow]
+		command = RowEdit(t.CursorTrack, t.CursorRow, row, t.ClearColumn())
+	case "p":
+		// Put below the cursor row, at the end of the pattern there is no room
+		if !clipboard.Empty() && t.CursorRow < t.NumRows-1 {
+			t.CursorRow++
+			command = t.Paste(*clipboard, PasteInsert)
+			t.scrollToCursor()
+		}
+	case "P":
+		command = t.Paste(*clipboard, PasteInsert)
+	case "i":
+		v.Insert = true
+	case "d", "y", "g", "m", "'", "`", "[", "]":
+		v.pending = key
+		if explicit {
+			v.pendingCount = strconv.Itoa(count)
+		} else {
+			v.pendingCount = ""
+		}
+	case "esc":
+		t.ClearSelection()
+	default:
+		return nil, nil, false
+	}
+
+	return command, cmd, true
+}
+
+// complete runs a two key command
+func (v *Vim) complete(prefix, key string, t *TrackerModel, clipboard *Clipboard) (Command, tea.Cmd) {
+	explicit := v.count != ""
+	count := v.takeCount(t.NumRows)
+
+	switch prefix + key {
+	case "dd":
+		*clipboard = t.yankRows(count)
+		return t.DeleteRows(count, false), nil
+	case "yy":
+		*clipboard = t.yankRows(count)
+	case "gg":
+		row := 0
+		if explicit {
+			row = count
+		}
+		t.GoToRow(row)
+	// The song is a single pattern, so the next and previous pattern are its ends
+	case "]]":
+		t.GoToRow(t.NumRows - 1)
+	case "[[":
+		t.GoToRow(0)
+	}
+
+	if len(key) != 1 || key[0] < 'a' || key[0] > 'z' {
+		return nil, nil
+	}
+
+	switch prefix {
+	case "m":
+		v.marks[key] = vimMark{track: t.CursorTrack, row: t.CursorRow}
+	case "'":
+		if mark, ok := v.marks[key]; ok {
+			t.GoToRow(mark.row)
+		}
+	case "`":
+		if mark, ok := v.marks[key]; ok {
+			t.GoToRow(mark.row)
+			if mark.track != t.CursorTrack {
+				return nil, t.SelectTrack(mark.track)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// takeCount returns the typed count clamped to limit, 1 without one, and resets it
+func (v *Vim) takeCount(limit int) int {
+	typed := v.count
+	v.count = ""
+	if typed == "" {
+		return 1
+	}
+
+	// Only digits are typed, so an error is a count too large for an int
+	count, err := strconv.Atoi(typed)
+	if err != nil || count > limit {
+		return max(limit, 1)
+	}
+	return max(count, 1)
+}
+
+// lastCmd keeps the track change of the last of repeated moves that changed the track
+func lastCmd(cmd, next tea.Cmd) tea.Cmd {
+	if next != nil {
+		return next
+	}
+	return cmd
+}
+
+// filledRow returns the row of the count-th filled cell below the cursor, or above for a
+// negative count, stopping at the pattern edges
+func (m *TrackerModel) filledRow(count int) int {
+	step := 1
+	if count < 0 {
+		step, count = -1, -count
+	}
+
+	rows := m.Tracks[m.CursorTrack].Rows
+	row := m.CursorRow
+	for next := row + step; next >= 0 && next < m.NumRows && count > 0; next += step {
+		if !isEmptyRow(rows[next]) {
+			row = next
+			count--
+		}
+	}
+	return row
+}
+
+// yankRows copies count rows of the current track starting at the cursor
+func (m *TrackerModel) yankRows(count int) Clipboard {
+	block := Block{Track: m.CursorTrack, Row: m.CursorRow, Tracks: 1, Rows: min(count, m.NumRows-m.CursorRow)}
+	return Clipboard{Tracks: m.block(block)}
+}
+
+// isEmptyRow reports whether a row holds no note, volume or effect
+func isEmptyRow(row TrackRow) bool {
+	return audio.IsOff(row.Note) && row.Volume == 0 && isEmptyEffect(row.Effect)
+}
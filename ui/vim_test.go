@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/tetrackt/tetrackt/audio"
+)
+
+func typeKeys(vim *Vim, tracker *TrackerModel, clipboard *Clipboard, keys ...string) []Command {
+	var commands []Command
+	for _, key := range keys {
+		if command, _, _ := vim.Handle(key, tracker, clipboard); command != nil {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+func TestVimMotions(t *testing.T) {
+	tracker := NewTracker(2, 64, 0, 20)
+	tracker.Tracks[0].Rows[10].Note = audio.NewNote(audio.BaseC, audio.Octave4)
+	tracker.Tracks[0].Rows[20].Volume = 32
+	vim := NewVim()
+	var clipboard Clipboard
+
+	typeKeys(vim, tracker, &clipboard, "1", "6", "j")
+	if tracker.CursorRow != 16 {
+		t.Errorf("Expected 16j to move to row 16, got %d", tracker.CursorRow)
+	}
+
+	typeKeys(vim, tracker, &clipboard, "g", "g", "w", "w")
+	if tracker.CursorRow != 20 {
+		t.Errorf("Expected ggww to reach the second filled row 20, got %d", tracker.CursorRow)
+	}
+
+	typeKeys(vim, tracker, &clipboard, "}", "2", "{")
+	if tracker.CursorRow != 16 {
+		t.Errorf("Expected beat motions to end on row 16, got %d", tracker.CursorRow)
+	}
+
+	typeKeys(vim, tracker, &clipboard, "m", "a", "G", "l", "l", "l", "l", "l", "l")
+	if tracker.CursorRow != 63 || tracker.CursorTrack != 1 {
+		t.Errorf("Expected G and l to reach row 63 of track 1, got row %d track %d", tracker.CursorRow, tracker.CursorTrack)
+	}
+
+	typeKeys(vim, tracker, &clipboard, "`", "a")
+	if tracker.CursorRow != 16 || tracker.CursorTrack != 0 {
+		t.Errorf("Expected mark a at row 16 of track 0, got row %d track %d", tracker.CursorRow, tracker.CursorTrack)
+	}
+
+	// Unknown keys are left to the key bindings, insert mode leaves all but esc
+	if _, _, ok := vim.Handle("s", tracker, &clipboard); ok {
+		t.Error("Expected s to be left to the key bindings")
+	}
+	typeKeys(vim, tracker, &clipboard, "i")
+	if _, _, ok := vim.Handle("j", tracker, &clipboard); ok || !vim.Insert {
+		t.Error("Expected j to be left to note entry in insert mode")
+	}
+	typeKeys(vim, tracker, &clipboard, "esc")
+	if vim.Insert {
+		t.Error("Expected esc to return to normal mode")
+	}
+}
+
+func TestVimRowCommands(t *testing.T) {
+	c4 := audio.NewNote(audio.BaseC, audio.Octave4)
+	e4 := audio.NewNote(audio.BaseE, audio.Octave4)
+
+	tracker := NewTracker(1, 8, 0, 20)
+	tracker.Tracks[0].Rows[0].Note = c4
+	tracker.Tracks[0].Rows[1].Note = e4
+	vim := NewVim()
+	var clipboard Clipboard
+
+	// 2dd removes both rows and yanks them
+	commands := typeKeys(vim, tracker, &clipboard, "2", "d", "d")
+	if !audio.IsOff(tracker.Tracks[0].Rows[0].Note) || len(clipboard.Tracks[0]) != 2 {
+		t.Errorf("Expected two rows deleted and yanked, got %+v", tracker.Tracks[0].Rows[:2])
+	}
+
+	// p puts them below the cursor row
+	commands = append(commands, typeKeys(vim, tracker, &clipboard, "p")...)
+	if tracker.Tracks[0].Rows[1].Note != c4 || tracker.Tracks[0].Rows[2].Note != e4 {
+		t.Errorf("Expected rows put below row 0, got %+v", tracker.Tracks[0].Rows[:3])
+	}
+
+	for i := len(commands) - 1; i >= 0; i-- {
+		commands[i].Undo(tracker)
+	}
+	if tracker.Tracks[0].Rows[0].Note != c4 || tracker.Tracks[0].Rows[1].Note != e4 {
+		t.Errorf("Expected undo to restore the rows, got %+v", tracker.Tracks[0].Rows[:2])
+	}
+}
+
+func TestVimCountClamped(t *testing.T) {
+	tracker := NewTracker(2, 16, 0, 20)
+	vim := NewVim()
+	var clipboard Clipboard
+
+	typeKeys(vim, tracker, &clipboard, "9", "9", "9", "9", "9", "9", "9", "9", "9", "9", "l")
+	if tracker.CursorTrack != 1 || tracker.CursorColumn != cellColumnCount-1 {
+		t.Errorf("Expected a huge count to stop at the last column, got track %d column %d", tracker.CursorTrack, tracker.CursorColumn)
+	}
+
+	var keys []string
+	for range 30 {
+		keys = append(keys, "9")
+	}
+	typeKeys(vim, tracker, &clipboard, append(keys, "j")...)
+	if tracker.CursorRow != 15 {
+		t.Errorf("Expected a count beyond int to move to the last row, got %d", tracker.CursorRow)
+	}
+}